@@ -1,21 +1,83 @@
 package cache
 
 import (
+	"context"
+	"fmt"
+	"slices"
 	"time"
 
-	"github.com/dgraph-io/ristretto/v2"
+	"github.com/wasilak/cloudpile/resources"
 )
 
+// Entry is the outcome of a single collector stored under its cache key.
+type Entry struct {
+	Items     []resources.Item `json:"items"`
+	Source    resources.Source `json:"source"`
+	UpdatedAt time.Time        `json:"updatedAt"`
+	// AttemptedAt is the time of the last collection, UpdatedAt is not moved by failed ones.
+	AttemptedAt time.Time `json:"attemptedAt,omitzero"`
+}
+
+// Backend stores cache entries.
+type Backend interface {
+	Get(key string) (Entry, bool)
+	// SetWithTTL stores entry until ttl passes, zero ttl keeps it until replaced.
+	SetWithTTL(key string, entry Entry, ttl time.Duration)
+	// Flush persists entries set so far, it is a no-op for in-memory backends.
+	Flush() error
+	// Keys lists keys of stored entries, some of them may have expired already.
+	Keys() ([]string, error)
+}
+
+// Locker is implemented by backends shared between replicas. Only the replica
+// holding the lock refreshes the cache, the others serve what it stored.
+type Locker interface {
+	// TryLock acquires or renews the lock for ttl and reports whether it is held.
+	TryLock(ctx context.Context, ttl time.Duration) (bool, error)
+	Unlock(ctx context.Context) error
+}
+
+// Store is implemented by backends shared between replicas. The replica
+// holding the lock shares state the others need to serve requests with it.
+type Store interface {
+	// Save stores value as JSON under key, until it is saved again.
+	Save(ctx context.Context, key string, value any) error
+	// Load decodes value stored under key and reports whether it was found.
+	Load(ctx context.Context, key string, value any) (bool, error)
+}
+
+// Config holds settings of the cache block of the config.
+type Config struct {
+	// Backend is one of memory (default), file or redis.
+	Backend string      `mapstructure:"backend"`
+	Path    string      `mapstructure:"path"`
+	Redis   RedisConfig `mapstructure:"redis"`
+	// TypeTTL overrides TTL per resource type.
+	TypeTTL map[string]string `mapstructure:"type_ttl"`
+	// StaleWhileRevalidate serves expired entries while refreshing them in
+	// background and refreshes missing entries on request.
+	StaleWhileRevalidate bool `mapstructure:"stale_while_revalidate"`
+	// MaxStale is how long expired entries are kept, empty keeps them until replaced.
+	MaxStale string `mapstructure:"max_stale"`
+}
+
 // Cache type
 type Cache struct {
-	Cache   *ristretto.Cache[string, interface{}]
+	Cache   Backend
 	TTL     time.Duration
 	Enabled bool
+	// Persistent is set for backends which keep entries across restarts.
+	Persistent           bool
+	TypeTTL              map[string]time.Duration
+	StaleWhileRevalidate bool
+	MaxStale             time.Duration
 }
 
 var CacheInstance Cache
 
-func InitCache(enabled bool, TTLString string) Cache {
+// InitCache builds cache from its config. TTLs have to be positive, they set
+// how often the cache is checked for expired entries.
+func InitCache(enabled bool, TTLString string, config Config) (Cache, error) {
 	var cacheInstance Cache
 	var cacheErr error
 
@@ -24,21 +86,131 @@ func InitCache(enabled bool, TTLString string) Cache {
 	}
 
 	cacheInstance.Enabled = enabled
+	cacheInstance.StaleWhileRevalidate = config.StaleWhileRevalidate
 
-	cacheInstance.TTL, cacheErr = time.ParseDuration(TTLString)
+	cacheInstance.TTL, cacheErr = parseTTL("cache.TTL", TTLString)
 	if cacheErr != nil {
-		panic(cacheErr)
+		return cacheInstance, cacheErr
+	}
+
+	cacheInstance.TypeTTL = map[string]time.Duration{}
+	for resourceType, ttl := range config.TypeTTL {
+		cacheInstance.TypeTTL[resourceType], cacheErr = parseTTL("cache.type_ttl."+resourceType, ttl)
+		if cacheErr != nil {
+			return cacheInstance, cacheErr
+		}
 	}
 
-	cacheInstance.Cache, cacheErr = ristretto.NewCache(&ristretto.Config[string, interface{}]{
-		NumCounters: 1e7,     // number of keys to track frequency of (10M).
-		MaxCost:     1 << 28, // maximum cost of cache (256mb).
-		BufferItems: 64,      // number of keys per Get buffer.
-	})
+	if config.MaxStale != "" {
+		cacheInstance.MaxStale, cacheErr = time.ParseDuration(config.MaxStale)
+		if cacheErr != nil {
+			return cacheInstance, fmt.Errorf("cache.max_stale: %w", cacheErr)
+		}
+		if cacheInstance.MaxStale < 0 {
+			return cacheInstance, fmt.Errorf("cache.max_stale: must not be negative, got %s", config.MaxStale)
+		}
+	}
 
-	if cacheErr != nil {
-		panic(cacheErr)
+	switch config.Backend {
+	case "", "memory":
+		cacheInstance.Cache, cacheErr = newRistrettoBackend()
+	case "file":
+		cacheInstance.Cache, cacheErr = newFileBackend(config.Path)
+		cacheInstance.Persistent = true
+	case "redis":
+		cacheInstance.Cache, cacheErr = newRedisBackend(config.Redis)
+		cacheInstance.Persistent = true
+	default:
+		cacheErr = fmt.Errorf("unknown cache backend %q, valid backends are: memory, file, redis", config.Backend)
+	}
+
+	return cacheInstance, cacheErr
+}
+
+// parseTTL parses a positive TTL of config key.
+func parseTTL(key, value string) (time.Duration, error) {
+	ttl, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", key, err)
+	}
+
+	if ttl <= 0 {
+		return 0, fmt.Errorf("%s: must be positive, got %s", key, value)
+	}
+
+	return ttl, nil
+}
+
+// TTLFor returns TTL of a resource type.
+func (c Cache) TTLFor(resourceType string) time.Duration {
+	if ttl, ok := c.TypeTTL[resourceType]; ok {
+		return ttl
+	}
+
+	return c.TTL
+}
+
+// MinTTL returns the shortest TTL of all resource types, it is how often
+// cache has to be checked for expired entries.
+func (c Cache) MinTTL() time.Duration {
+	minTTL := c.TTL
+	for _, ttl := range c.TypeTTL {
+		minTTL = min(minTTL, ttl)
+	}
+
+	return minTTL
+}
+
+// Expired reports whether entry of a resource type is older than its TTL at
+// time at.
+func (c Cache) Expired(entry Entry, resourceType string, at time.Time) bool {
+	return !entry.UpdatedAt.Add(c.TTLFor(resourceType)).After(at)
+}
+
+// Set stores entry of a resource type, keeping it MaxStale past its TTL.
+func (c Cache) Set(key string, resourceType string, entry Entry) {
+	var ttl time.Duration
+	if c.MaxStale > 0 {
+		ttl = c.TTLFor(resourceType) + c.MaxStale
+	}
+
+	c.Cache.SetWithTTL(key, entry, ttl)
+}
+
+// Status describes a single cache entry.
+type Status struct {
+	Key string `json:"key"`
+	resources.Source
+	UpdatedAt   time.Time `json:"updatedAt"`
+	AttemptedAt time.Time `json:"attemptedAt,omitzero"`
+	Expired     bool      `json:"expired"`
+}
+
+// Statuses lists status of every stored entry sorted by key.
+func (c Cache) Statuses() ([]Status, error) {
+	keys, err := c.Cache.Keys()
+	if err != nil {
+		return nil, err
+	}
+
+	slices.Sort(keys)
+
+	now := time.Now()
+	statuses := []Status{}
+	for _, key := range keys {
+		entry, found := c.Cache.Get(key)
+		if !found {
+			continue
+		}
+
+		statuses = append(statuses, Status{
+			Key:         key,
+			Source:      entry.Source,
+			UpdatedAt:   entry.UpdatedAt,
+			AttemptedAt: entry.AttemptedAt,
+			Expired:     c.Expired(entry, entry.Source.Type, now),
+		})
 	}
 
-	return cacheInstance
+	return statuses, nil
 }
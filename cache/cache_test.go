@@ -0,0 +1,46 @@
+package cache
+
+import (
+	"testing"
+	"time"
+)
+
+func TestInitCacheTTL(t *testing.T) {
+	tests := []struct {
+		name    string
+		ttl     string
+		config  Config
+		wantErr bool
+	}{
+		{name: "defaults", ttl: ""},
+		{name: "type ttl", ttl: "5m", config: Config{TypeTTL: map[string]string{"sg": "30s"}, MaxStale: "0s"}},
+		{name: "zero ttl", ttl: "0s", wantErr: true},
+		{name: "negative ttl", ttl: "-1m", wantErr: true},
+		{name: "invalid ttl", ttl: "soon", wantErr: true},
+		{name: "zero type ttl", ttl: "5m", config: Config{TypeTTL: map[string]string{"sg": "0s"}}, wantErr: true},
+		{name: "negative type ttl", ttl: "5m", config: Config{TypeTTL: map[string]string{"sg": "-1m"}}, wantErr: true},
+		{name: "negative max stale", ttl: "5m", config: Config{MaxStale: "-1h"}, wantErr: true},
+		{name: "unknown backend", ttl: "5m", config: Config{Backend: "disk"}, wantErr: true},
+		{name: "redis without address", ttl: "5m", config: Config{Backend: "redis"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		c, err := InitCache(true, tt.ttl, tt.config)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: InitCache error = %v, want error %v", tt.name, err, tt.wantErr)
+			continue
+		}
+
+		if err == nil && c.MinTTL() <= 0 {
+			t.Errorf("%s: MinTTL = %s, want positive", tt.name, c.MinTTL())
+		}
+	}
+
+	c, err := InitCache(true, "5m", Config{TypeTTL: map[string]string{"sg": "30s"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.TTLFor("sg") != 30*time.Second || c.TTLFor("ec2") != 5*time.Minute || c.MinTTL() != 30*time.Second {
+		t.Errorf("TTLFor(sg), TTLFor(ec2), MinTTL = %s, %s, %s, want 30s, 5m, 30s", c.TTLFor("sg"), c.TTLFor("ec2"), c.MinTTL())
+	}
+}
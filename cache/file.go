@@ -0,0 +1,116 @@
+package cache
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"log/slog"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+)
+
+// fileBackend keeps entries in memory and snapshots them to a JSON file, so
+// that restarts do not begin with an empty cache.
+type fileBackend struct {
+	path    string
+	mu      sync.RWMutex
+	entries map[string]fileEntry
+}
+
+// fileEntry is an entry with its expiration time, zero ExpiresAt never expires.
+type fileEntry struct {
+	Entry     Entry     `json:"entry"`
+	ExpiresAt time.Time `json:"expiresAt,omitzero"`
+}
+
+func newFileBackend(path string) (*fileBackend, error) {
+	if path == "" {
+		path = "cloudpile-cache.json"
+	}
+
+	b := &fileBackend{
+		path:    path,
+		entries: map[string]fileEntry{},
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return b, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// a broken snapshot only means a cold start
+	if err := json.Unmarshal(data, &b.entries); err != nil {
+		slog.Warn("Ignoring unreadable cache snapshot", "path", path, "error", err)
+		b.entries = map[string]fileEntry{}
+	}
+
+	slog.Debug("Cache snapshot loaded", "path", path, "entries", len(b.entries))
+
+	return b, nil
+}
+
+func (b *fileBackend) Get(key string) (Entry, bool) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	entry, ok := b.entries[key]
+	if !ok || (!entry.ExpiresAt.IsZero() && entry.ExpiresAt.Before(time.Now())) {
+		return Entry{}, false
+	}
+
+	return entry.Entry, true
+}
+
+func (b *fileBackend) SetWithTTL(key string, entry Entry, ttl time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	stored := fileEntry{Entry: entry}
+	if ttl > 0 {
+		stored.ExpiresAt = time.Now().Add(ttl)
+	}
+
+	b.entries[key] = stored
+}
+
+func (b *fileBackend) Keys() ([]string, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	return slices.Collect(maps.Keys(b.entries)), nil
+}
+
+// Flush writes snapshot to a temporary file first and renames it, so a crash
+// never leaves a truncated snapshot behind.
+func (b *fileBackend) Flush() error {
+	b.mu.RLock()
+	data, err := json.Marshal(b.entries)
+	b.mu.RUnlock()
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(b.path), filepath.Base(b.path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), b.path)
+}
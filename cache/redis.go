@@ -0,0 +1,172 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	redisKeyPrefix   = "cloudpile:cache:"
+	redisStatePrefix = "cloudpile:state:"
+	redisLockKey     = "cloudpile:leader"
+	redisTimeout     = 5 * time.Second
+)
+
+// renewLock extends lock only when it is still held by this replica.
+var renewLock = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0
+`)
+
+// releaseLock deletes lock only when it is still held by this replica.
+var releaseLock = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+// RedisConfig holds connection settings of the redis backend.
+type RedisConfig struct {
+	Address  string `mapstructure:"address"`
+	Password string `mapstructure:"password"`
+	DB       int    `mapstructure:"db"`
+}
+
+// redisBackend shares entries between replicas. It also implements Locker,
+// so that only one replica refreshes the shared cache, and Store.
+type redisBackend struct {
+	client *redis.Client
+	id     string
+}
+
+func newRedisBackend(config RedisConfig) (*redisBackend, error) {
+	if config.Address == "" {
+		return nil, errors.New("cache.redis.address is required for redis cache backend")
+	}
+
+	hostname, err := os.Hostname()
+	if err != nil {
+		return nil, err
+	}
+
+	b := &redisBackend{
+		client: redis.NewClient(&redis.Options{
+			Addr:     config.Address,
+			Password: config.Password,
+			DB:       config.DB,
+		}),
+		id: hostname + "-" + time.Now().Format(time.RFC3339Nano),
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+
+	if err := b.client.Ping(ctx).Err(); err != nil {
+		return nil, err
+	}
+
+	return b, nil
+}
+
+func (b *redisBackend) Get(key string) (Entry, bool) {
+	var entry Entry
+
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+
+	data, err := b.client.Get(ctx, redisKeyPrefix+key).Bytes()
+	if err != nil {
+		if !errors.Is(err, redis.Nil) {
+			slog.Error("Cache read failed", "cache_key", key, "error", err)
+		}
+		return entry, false
+	}
+
+	if err := json.Unmarshal(data, &entry); err != nil {
+		slog.Error("Cache read failed", "cache_key", key, "error", err)
+		return entry, false
+	}
+
+	return entry, true
+}
+
+func (b *redisBackend) SetWithTTL(key string, entry Entry, ttl time.Duration) {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		slog.Error("Cache write failed", "cache_key", key, "error", err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+
+	if err := b.client.Set(ctx, redisKeyPrefix+key, data, ttl).Err(); err != nil {
+		slog.Error("Cache write failed", "cache_key", key, "error", err)
+	}
+}
+
+func (b *redisBackend) Flush() error {
+	return nil
+}
+
+func (b *redisBackend) Keys() ([]string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+
+	keys := []string{}
+	iter := b.client.Scan(ctx, 0, redisKeyPrefix+"*", 0).Iterator()
+	for iter.Next(ctx) {
+		keys = append(keys, strings.TrimPrefix(iter.Val(), redisKeyPrefix))
+	}
+
+	return keys, iter.Err()
+}
+
+func (b *redisBackend) TryLock(ctx context.Context, ttl time.Duration) (bool, error) {
+	acquired, err := b.client.SetNX(ctx, redisLockKey, b.id, ttl).Result()
+	if err != nil || acquired {
+		return acquired, err
+	}
+
+	renewed, err := renewLock.Run(ctx, b.client, []string{redisLockKey}, b.id, ttl.Milliseconds()).Int()
+	if err != nil {
+		return false, err
+	}
+
+	return renewed == 1, nil
+}
+
+func (b *redisBackend) Unlock(ctx context.Context) error {
+	return releaseLock.Run(ctx, b.client, []string{redisLockKey}, b.id).Err()
+}
+
+func (b *redisBackend) Save(ctx context.Context, key string, value any) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	return b.client.Set(ctx, redisStatePrefix+key, data, 0).Err()
+}
+
+func (b *redisBackend) Load(ctx context.Context, key string, value any) (bool, error) {
+	data, err := b.client.Get(ctx, redisStatePrefix+key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return true, json.Unmarshal(data, value)
+}
@@ -0,0 +1,180 @@
+package cache
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/wasilak/cloudpile/resources"
+)
+
+func newTestRedisBackend(t *testing.T, server *miniredis.Miniredis, id string) *redisBackend {
+	t.Helper()
+
+	b, err := newRedisBackend(RedisConfig{Address: server.Addr()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { b.client.Close() })
+
+	b.id = id
+
+	return b
+}
+
+func TestRedisBackendEntries(t *testing.T) {
+	server := miniredis.RunT(t)
+	b := newTestRedisBackend(t, server, "replica-1")
+
+	if _, found := b.Get("missing"); found {
+		t.Fatal("Get of missing key found an entry")
+	}
+
+	entry := Entry{
+		Items:     []resources.Item{{ID: "i-1", Type: "EC2 instance"}},
+		Source:    resources.Source{Account: "123456789012", Region: "eu-central-1", Type: "ec2", ItemCount: 1},
+		UpdatedAt: time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC),
+	}
+
+	b.SetWithTTL("a", entry, time.Minute)
+	b.SetWithTTL("b", entry, 0)
+
+	got, found := b.Get("a")
+	if !found {
+		t.Fatal("Get of stored key found no entry")
+	}
+	if got.Items[0].ID != "i-1" || got.Source != entry.Source || !got.UpdatedAt.Equal(entry.UpdatedAt) {
+		t.Errorf("Get = %+v, want %+v", got, entry)
+	}
+
+	keys, err := b.Keys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	slices.Sort(keys)
+	if !slices.Equal(keys, []string{"a", "b"}) {
+		t.Errorf("Keys = %v, want [a b]", keys)
+	}
+
+	// only entries stored with ttl expire
+	server.FastForward(2 * time.Minute)
+
+	if _, found := b.Get("a"); found {
+		t.Error("Get of expired key found an entry")
+	}
+	if _, found := b.Get("b"); !found {
+		t.Error("Get of key stored without ttl found no entry")
+	}
+
+	// keys of other applications are not listed
+	server.Set("other", "value")
+
+	keys, err = b.Keys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(keys, []string{"b"}) {
+		t.Errorf("Keys = %v, want [b]", keys)
+	}
+}
+
+func TestRedisBackendLock(t *testing.T) {
+	ctx := context.Background()
+	server := miniredis.RunT(t)
+	leader := newTestRedisBackend(t, server, "replica-1")
+	follower := newTestRedisBackend(t, server, "replica-2")
+
+	tests := []struct {
+		name    string
+		backend *redisBackend
+		want    bool
+	}{
+		{"first replica acquires", leader, true},
+		{"other replica is refused", follower, false},
+		{"holder renews", leader, true},
+	}
+
+	for _, tt := range tests {
+		held, err := tt.backend.TryLock(ctx, time.Minute)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if held != tt.want {
+			t.Errorf("%s: TryLock = %v, want %v", tt.name, held, tt.want)
+		}
+	}
+
+	// renewal extends ttl instead of keeping the original one
+	server.FastForward(50 * time.Second)
+	if _, err := leader.TryLock(ctx, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	server.FastForward(50 * time.Second)
+	if got := server.TTL(redisLockKey); got <= 0 {
+		t.Errorf("lock ttl after renewal = %v, want it still held", got)
+	}
+
+	// only the holder releases the lock
+	if err := follower.Unlock(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := server.Get(redisLockKey); got != "replica-1" {
+		t.Errorf("lock holder after foreign Unlock = %q, want replica-1", got)
+	}
+
+	if err := leader.Unlock(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if server.Exists(redisLockKey) {
+		t.Error("lock still held after Unlock of holder")
+	}
+
+	if held, err := follower.TryLock(ctx, time.Minute); err != nil || !held {
+		t.Errorf("TryLock after release = %v, %v, want true", held, err)
+	}
+
+	// expired lock is free for others
+	server.FastForward(2 * time.Minute)
+
+	if held, err := leader.TryLock(ctx, time.Minute); err != nil || !held {
+		t.Errorf("TryLock after expiry = %v, %v, want true", held, err)
+	}
+	if held, err := follower.TryLock(ctx, time.Minute); err != nil || held {
+		t.Errorf("TryLock of previous holder after expiry = %v, %v, want false", held, err)
+	}
+}
+
+func TestRedisBackendStore(t *testing.T) {
+	ctx := context.Background()
+	server := miniredis.RunT(t)
+	leader := newTestRedisBackend(t, server, "replica-1")
+	follower := newTestRedisBackend(t, server, "replica-2")
+
+	var regions map[string][]string
+	if found, err := follower.Load(ctx, "regions", &regions); err != nil || found {
+		t.Fatalf("Load of missing key = %v, %v, want false", found, err)
+	}
+
+	if err := leader.Save(ctx, "regions", map[string][]string{"prod": {"eu-central-1"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	found, err := follower.Load(ctx, "regions", &regions)
+	if err != nil || !found {
+		t.Fatalf("Load of saved key = %v, %v, want true", found, err)
+	}
+	if !slices.Equal(regions["prod"], []string{"eu-central-1"}) {
+		t.Errorf("Load = %v, want map[prod:[eu-central-1]]", regions)
+	}
+
+	// shared state is not a cache entry
+	keys, err := leader.Keys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != 0 {
+		t.Errorf("Keys = %v, want none", keys)
+	}
+}
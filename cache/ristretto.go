@@ -0,0 +1,55 @@
+package cache
+
+import (
+	"sync"
+	"time"
+
+	"github.com/dgraph-io/ristretto/v2"
+)
+
+// ristrettoBackend keeps entries in memory only.
+type ristrettoBackend struct {
+	cache *ristretto.Cache[string, Entry]
+	// ristretto can't list its keys, so they are tracked separately
+	keys sync.Map
+}
+
+func newRistrettoBackend() (*ristrettoBackend, error) {
+	cache, err := ristretto.NewCache(&ristretto.Config[string, Entry]{
+		NumCounters: 1e7,     // number of keys to track frequency of (10M).
+		MaxCost:     1 << 28, // maximum cost of cache (256mb).
+		BufferItems: 64,      // number of keys per Get buffer.
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &ristrettoBackend{cache: cache}, nil
+}
+
+func (b *ristrettoBackend) Get(key string) (Entry, bool) {
+	return b.cache.Get(key)
+}
+
+func (b *ristrettoBackend) SetWithTTL(key string, entry Entry, ttl time.Duration) {
+	// set a value with a cost of 1
+	b.cache.SetWithTTL(key, entry, 1, ttl)
+	b.keys.Store(key, struct{}{})
+
+	// wait for value to pass through buffers
+	b.cache.Wait()
+}
+
+func (b *ristrettoBackend) Flush() error {
+	return nil
+}
+
+func (b *ristrettoBackend) Keys() ([]string, error) {
+	keys := []string{}
+	b.keys.Range(func(key, _ any) bool {
+		keys = append(keys, key.(string))
+		return true
+	})
+
+	return keys, nil
+}
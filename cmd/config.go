@@ -0,0 +1,34 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/wasilak/cloudpile/libs"
+)
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Inspect configuration",
+}
+
+// configValidateCmd relies on the config check run before it, which prints
+// all problems and exits non-zero.
+var configValidateCmd = &cobra.Command{
+	Use:   "validate",
+	Short: "Validate configuration file and exit",
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SetContext(ctx)
+		cmd.SilenceUsage = true
+		return libs.CheckConfig()
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		fmt.Printf("%s: configuration is valid\n", viper.ConfigFileUsed())
+		return nil
+	},
+}
+
+func init() {
+	configCmd.AddCommand(configValidateCmd)
+}
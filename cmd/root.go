@@ -2,9 +2,13 @@ package cmd
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
+	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -18,8 +22,10 @@ var (
 	rootCmd = &cobra.Command{
 		Use:   libs.AppName,
 		Short: "Cross account AWS resources directory",
-		PreRun: func(cmd *cobra.Command, args []string) {
+		PreRunE: func(cmd *cobra.Command, args []string) error {
 			cmd.SetContext(ctx)
+			cmd.SilenceUsage = true
+			return libs.CheckConfig()
 		},
 		Run: func(cmd *cobra.Command, args []string) {
 			var err error
@@ -35,12 +41,30 @@ var (
 				os.Exit(1)
 			}
 
+			var stop context.CancelFunc
+			ctx, stop = signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
 			if viper.GetBool("cache.enabled") {
-				cache.CacheInstance = cache.InitCache(viper.GetBool("cache.enabled"), viper.GetString("cache.TTL"))
-				libs.Runner()
+				var cacheConfig cache.Config
+				if err := viper.UnmarshalKey("cache", &cacheConfig); err != nil {
+					slog.Error(err.Error())
+					os.Exit(1)
+				}
+
+				cache.CacheInstance, err = cache.InitCache(viper.GetBool("cache.enabled"), viper.GetString("cache.TTL"), cacheConfig)
+				if err != nil {
+					slog.Error(err.Error())
+					os.Exit(1)
+				}
+
+				libs.Runner(ctx)
 			}
 
-			web.Web()
+			if err := web.Web(ctx); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				slog.Error(err.Error())
+				os.Exit(1)
+			}
 		},
 	}
 	ctx = context.Background()
@@ -63,4 +87,5 @@ func init() {
 	cobra.OnInitialize(libs.InitConfig)
 
 	rootCmd.AddCommand(versionCmd)
+	rootCmd.AddCommand(configCmd)
 }
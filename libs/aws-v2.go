@@ -2,48 +2,145 @@ package libs
 
 import (
 	"context"
+	"fmt"
+	"os"
+	"strings"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
 	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
 	"github.com/aws/aws-sdk-go-v2/service/sts"
+	"github.com/aws/smithy-go/middleware"
 )
 
-func newAWSV2Config(awsConfig AWSConfig, region string) (aws.Config, error) {
-	var cfg aws.Config
-	var err error
-
-	if awsConfig.Type == "iam" {
-		client := sts.NewFromConfig(cfg)
-
-		cfg, err = config.LoadDefaultConfig(context.TODO(),
-			config.WithRegion(region),
-			config.WithCredentialsProvider(aws.NewCredentialsCache(
-				stscreds.NewAssumeRoleProvider(
-					client,
-					awsConfig.IAMRoleARN,
-				)),
-			),
-		)
-	} else if awsConfig.Type == "profile" {
-		cfg, err = config.LoadDefaultConfig(context.TODO(),
-			config.WithRegion(region),
-			config.WithSharedConfigProfile(awsConfig.Profile),
-		)
+// defaultSessionName is the role session name used unless session_name is set.
+const defaultSessionName = "cloudpile"
+
+// newAWSV2Config loads base credentials of awsConfig type and assumes
+// hub_role_arn and iam_role_arn on top of them, in this order.
+func newAWSV2Config(ctx context.Context, awsConfig AWSConfig, region string) (aws.Config, error) {
+	opts := []func(*config.LoadOptions) error{
+		config.WithRegion(region),
+		config.WithRetryer(newRetryer(awsConfig.Name())),
+		config.WithAPIOptions([]func(*middleware.Stack) error{
+			withRateLimit(awsConfig.Name()),
+		}),
+	}
+
+	switch awsConfig.Type {
+	case "default", "iam":
+		// SDK default chain: env, shared config, web identity, container and instance roles
+
+	case "profile", "sso":
+		opts = append(opts, config.WithSharedConfigProfile(awsConfig.Profile))
+
+	case "static":
+		accessKeyID, err := secretValue(awsConfig.AccessKeyID)
+		if err != nil {
+			return aws.Config{}, err
+		}
+
+		secretAccessKey, err := secretValue(awsConfig.SecretAccessKey)
+		if err != nil {
+			return aws.Config{}, err
+		}
+
+		sessionToken, err := secretValue(awsConfig.SessionToken)
+		if err != nil {
+			return aws.Config{}, err
+		}
+
+		opts = append(opts, config.WithCredentialsProvider(
+			credentials.NewStaticCredentialsProvider(accessKeyID, secretAccessKey, sessionToken),
+		))
+
+	case "web_identity":
+		// anonymous STS client, the call is authorized by the token itself
+		stsConfig, err := config.LoadDefaultConfig(ctx, config.WithRegion(region), config.WithCredentialsProvider(aws.AnonymousCredentials{}))
+		if err != nil {
+			return aws.Config{}, err
+		}
+
+		roleARN, tokenFile := awsConfig.webIdentity()
+
+		opts = append(opts, config.WithCredentialsProvider(aws.NewCredentialsCache(
+			stscreds.NewWebIdentityRoleProvider(sts.NewFromConfig(stsConfig), roleARN, stscreds.IdentityTokenFile(tokenFile),
+				func(o *stscreds.WebIdentityRoleOptions) {
+					o.RoleSessionName = awsConfig.sessionName()
+				}),
+		)))
+
+	default:
+		return aws.Config{}, fmt.Errorf("unknown type %q of account %q", awsConfig.Type, awsConfig.Name())
 	}
 
+	cfg, err := config.LoadDefaultConfig(ctx, opts...)
 	if err != nil {
 		return cfg, err
 	}
 
+	// hub role is only a stepping stone, external ID guards the target role
+	if awsConfig.HubRoleARN != "" {
+		cfg.Credentials = assumeRole(cfg, awsConfig, awsConfig.HubRoleARN, "")
+	}
+
+	externalID := awsConfig.ExternalID
+	if awsConfig.managementRoleARN != "" {
+		cfg.Credentials = assumeRole(cfg, awsConfig, awsConfig.managementRoleARN, externalID)
+		externalID = ""
+	}
+
+	if awsConfig.IAMRoleARN != "" {
+		cfg.Credentials = assumeRole(cfg, awsConfig, awsConfig.IAMRoleARN, externalID)
+	}
+
 	return cfg, nil
 }
 
-func getAccountId(cfg aws.Config) (string, error) {
+// assumeRole returns cached credentials of roleARN, assumed with cfg credentials.
+func assumeRole(cfg aws.Config, awsConfig AWSConfig, roleARN, externalID string) aws.CredentialsProvider {
+	return aws.NewCredentialsCache(stscreds.NewAssumeRoleProvider(sts.NewFromConfig(cfg), roleARN,
+		func(o *stscreds.AssumeRoleOptions) {
+			o.RoleSessionName = awsConfig.sessionName()
+
+			if externalID != "" {
+				o.ExternalID = aws.String(externalID)
+			}
+
+			if awsConfig.SessionDuration > 0 {
+				o.Duration = awsConfig.SessionDuration
+			}
+		}))
+}
+
+// secretValue resolves value given as env:NAME or file:/path, other values
+// are returned as they are.
+func secretValue(value string) (string, error) {
+	if name, ok := strings.CutPrefix(value, "env:"); ok {
+		secret, found := os.LookupEnv(name)
+		if !found {
+			return "", fmt.Errorf("environment variable %s is not set", name)
+		}
+		return secret, nil
+	}
+
+	if path, ok := strings.CutPrefix(value, "file:"); ok {
+		secret, err := os.ReadFile(path)
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimSpace(string(secret)), nil
+	}
+
+	return value, nil
+}
+
+func getAccountId(ctx context.Context, cfg aws.Config) (string, error) {
 	client := sts.NewFromConfig(cfg)
 	input := &sts.GetCallerIdentityInput{}
 
-	req, err := client.GetCallerIdentity(context.TODO(), input)
+	req, err := client.GetCallerIdentity(ctx, input)
 	if err != nil {
 		return "", err
 	}
@@ -1,11 +1,17 @@
 package libs
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
+	"log/slog"
 	"os"
 	"strings"
+	"time"
 
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
+	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/joho/godotenv"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -17,15 +23,113 @@ var (
 	Listen       string
 	CacheEnabled bool
 	AWSConfigs   []AWSConfig
+
+	// configErr is the error reading config file, reported by CheckConfig
+	configErr error
 )
 
 type AWSConfig struct {
-	Type         string   `mapstructure:"type"`
-	IAMRoleARN   string   `mapstructure:"iam_role_arn"`
-	Profile      string   `mapstructure:"profile"`
-	AccountAlias string   `mapstructure:"account_alias"`
-	Regions      []string `mapstructure:"regions"`
-	Resources    []string `mapstructure:"resources"`
+	Type           string   `mapstructure:"type"`
+	IAMRoleARN     string   `mapstructure:"iam_role_arn"`
+	Profile        string   `mapstructure:"profile"`
+	AccountAlias   string   `mapstructure:"account_alias"`
+	Regions        []string `mapstructure:"regions"`
+	ExcludeRegions []string `mapstructure:"exclude_regions"`
+	Resources      []string `mapstructure:"resources"`
+
+	// role chaining: HubRoleARN is assumed first, then IAMRoleARN with ExternalID
+	HubRoleARN      string        `mapstructure:"hub_role_arn"`
+	ExternalID      string        `mapstructure:"external_id"`
+	SessionName     string        `mapstructure:"session_name"`
+	SessionDuration time.Duration `mapstructure:"session_duration"`
+
+	// web_identity type, defaults to AWS_ROLE_ARN and AWS_WEB_IDENTITY_TOKEN_FILE set by EKS
+	WebIdentityRoleARN   string `mapstructure:"web_identity_role_arn"`
+	WebIdentityTokenFile string `mapstructure:"web_identity_token_file"`
+
+	// static type, values can be given as env:NAME or file:/path
+	AccessKeyID     string `mapstructure:"access_key_id"`
+	SecretAccessKey string `mapstructure:"secret_access_key"`
+	SessionToken    string `mapstructure:"session_token"`
+
+	// RoleTemplate is the role assumed into member accounts of organization
+	// type, {account_id} is replaced with the account ID.
+	RoleTemplate string          `mapstructure:"role_template"`
+	Include      AccountSelector `mapstructure:"include"`
+	Exclude      AccountSelector `mapstructure:"exclude"`
+	// managementRoleARN of organization members is assumed between HubRoleARN
+	// and IAMRoleARN, ExternalID then guards it instead of IAMRoleARN.
+	managementRoleARN string
+}
+
+// Name identifies account config in logs and metrics.
+func (c AWSConfig) Name() string {
+	if c.AccountAlias != "" {
+		return c.AccountAlias
+	}
+
+	if c.IAMRoleARN != "" {
+		return c.IAMRoleARN
+	}
+
+	return c.Profile
+}
+
+// redactedAWSConfig has no LogValue, so it is logged field by field.
+type redactedAWSConfig AWSConfig
+
+// LogValue keeps secrets of account config out of logs.
+func (c AWSConfig) LogValue() slog.Value {
+	return slog.AnyValue(c.redacted())
+}
+
+// redacted returns copy of config with secrets replaced, for logging.
+func (c AWSConfig) redacted() redactedAWSConfig {
+	for _, secret := range []*string{&c.SecretAccessKey, &c.SessionToken, &c.ExternalID} {
+		if *secret != "" {
+			*secret = "REDACTED"
+		}
+	}
+
+	return redactedAWSConfig(c)
+}
+
+func (c AWSConfig) sessionName() string {
+	if c.SessionName != "" {
+		return c.SessionName
+	}
+
+	return defaultSessionName
+}
+
+// webIdentity returns role and token file of web_identity type.
+func (c AWSConfig) webIdentity() (string, string) {
+	roleARN, tokenFile := c.WebIdentityRoleARN, c.WebIdentityTokenFile
+
+	if roleARN == "" {
+		roleARN = os.Getenv("AWS_ROLE_ARN")
+	}
+
+	if tokenFile == "" {
+		tokenFile = os.Getenv("AWS_WEB_IDENTITY_TOKEN_FILE")
+	}
+
+	return roleARN, tokenFile
+}
+
+// managementConfig returns config of organization management account. It
+// uses profile when set, the SDK default chain otherwise, and assumes
+// iam_role_arn through hub_role_arn when set.
+func (c AWSConfig) managementConfig() AWSConfig {
+	management := c
+	management.Type = "default"
+	management.Regions, management.Resources = nil, nil
+
+	if c.Profile != "" {
+		management.Type = "profile"
+	}
+
+	return management
 }
 
 func InitConfig() {
@@ -36,6 +140,7 @@ func InitConfig() {
 	viper.SetEnvPrefix(AppName)
 
 	viper.SetDefault("logformat", "plain")
+	viper.SetDefault("collectors.max_concurrency", 10)
 
 	if CfgFile != "" {
 		// Use config file from the flag.
@@ -55,13 +160,115 @@ func InitConfig() {
 	if err := viper.ReadInConfig(); err == nil {
 		fmt.Println("Using config file:", viper.ConfigFileUsed())
 	} else {
+		configErr = fmt.Errorf("reading config: %w", err)
 		log.Printf("%+v\n", err)
 	}
+}
+
+// CheckConfig validates the config file, printing its problems, and loads
+// accounts. Commands working with the config run it before they start.
+func CheckConfig() error {
+	if configErr != nil {
+		return configErr
+	}
+
+	problems, err := ValidateConfig(viper.ConfigFileUsed())
+	if err != nil {
+		return err
+	}
 
-	viper.UnmarshalKey("aws", &AWSConfigs)
+	if len(problems) > 0 {
+		for _, problem := range problems {
+			fmt.Fprintf(os.Stderr, "%s: %s\n", viper.ConfigFileUsed(), problem)
+		}
+		return fmt.Errorf("%s: %d problem(s) found", viper.ConfigFileUsed(), len(problems))
+	}
+
+	if err := viper.UnmarshalKey("aws", &AWSConfigs); err != nil {
+		return err
+	}
 
 	if strings.ToLower(viper.GetString("loglevel")) == "debug" {
-		log.Printf("%+v", viper.AllSettings())
-		log.Printf("%+v", AWSConfigs)
+		for _, awsConfig := range AWSConfigs {
+			log.Printf("%+v", awsConfig.redacted())
+		}
+	}
+
+	return nil
+}
+
+// validateCredentials checks that all settings credentials of awsConfig type
+// need are present, without calling AWS.
+func validateCredentials(awsConfig AWSConfig) error {
+	for _, role := range [][2]string{
+		{"iam_role_arn", awsConfig.IAMRoleARN},
+		{"hub_role_arn", awsConfig.HubRoleARN},
+		{"web_identity_role_arn", awsConfig.WebIdentityRoleARN},
+	} {
+		if role[1] != "" && !arn.IsARN(role[1]) {
+			return fmt.Errorf("%s %q is not a valid ARN", role[0], role[1])
+		}
 	}
+
+	if awsConfig.SessionDuration != 0 && (awsConfig.SessionDuration < 15*time.Minute || awsConfig.SessionDuration > 12*time.Hour) {
+		return fmt.Errorf("session_duration %s must be between 15m and 12h", awsConfig.SessionDuration)
+	}
+
+	switch awsConfig.Type {
+	case "default", "profile":
+
+	case "iam":
+		if awsConfig.IAMRoleARN == "" {
+			return errors.New("type iam requires iam_role_arn")
+		}
+
+	case "sso":
+		if awsConfig.Profile == "" {
+			return errors.New("type sso requires profile configured with sso_session or sso_start_url in AWS config")
+		}
+
+		profile, err := config.LoadSharedConfigProfile(context.Background(), awsConfig.Profile)
+		if err != nil {
+			return fmt.Errorf("sso profile %q: %w", awsConfig.Profile, err)
+		}
+
+		if profile.SSOSessionName == "" && profile.SSOStartURL == "" {
+			return fmt.Errorf("profile %q has no sso_session or sso_start_url", awsConfig.Profile)
+		}
+
+	case "web_identity":
+		roleARN, tokenFile := awsConfig.webIdentity()
+		if roleARN == "" {
+			return errors.New("type web_identity requires web_identity_role_arn or AWS_ROLE_ARN")
+		}
+
+		if tokenFile == "" {
+			return errors.New("type web_identity requires web_identity_token_file or AWS_WEB_IDENTITY_TOKEN_FILE")
+		}
+
+		if _, err := os.Stat(tokenFile); err != nil {
+			return fmt.Errorf("web identity token file: %w", err)
+		}
+
+	case "static":
+		if awsConfig.AccessKeyID == "" || awsConfig.SecretAccessKey == "" {
+			return errors.New("type static requires access_key_id and secret_access_key")
+		}
+
+		for _, value := range []string{awsConfig.AccessKeyID, awsConfig.SecretAccessKey, awsConfig.SessionToken} {
+			if _, err := secretValue(value); err != nil {
+				return err
+			}
+		}
+
+	case "organization":
+		if awsConfig.RoleTemplate != "" && !strings.Contains(awsConfig.RoleTemplate, "{account_id}") {
+			return fmt.Errorf("role_template %q has no {account_id} placeholder", awsConfig.RoleTemplate)
+		}
+
+	default:
+		return fmt.Errorf("unknown type %q, valid types are: default, profile, iam, sso, web_identity, static, organization", awsConfig.Type)
+	}
+
+	return nil
 }
@@ -0,0 +1,38 @@
+package libs
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestAWSConfigLogValue(t *testing.T) {
+	awsConfig := AWSConfig{
+		Type:            "static",
+		AccountAlias:    "prod",
+		AccessKeyID:     "AKIAEXAMPLE",
+		SecretAccessKey: "secret-access-key",
+		SessionToken:    "session-token",
+		ExternalID:      "external-id",
+	}
+
+	var out bytes.Buffer
+	slog.New(slog.NewTextHandler(&out, nil)).Info("test", "awsConfig", awsConfig)
+	fmt.Fprintf(&out, "%+v", awsConfig.redacted())
+
+	for _, secret := range []string{"secret-access-key", "session-token", "external-id"} {
+		if strings.Contains(out.String(), secret) {
+			t.Errorf("log output contains %q: %s", secret, out.String())
+		}
+	}
+
+	if !strings.Contains(out.String(), "prod") {
+		t.Errorf("log output misses account alias: %s", out.String())
+	}
+
+	if awsConfig.SecretAccessKey != "secret-access-key" {
+		t.Error("redacting modified the account config")
+	}
+}
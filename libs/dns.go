@@ -0,0 +1,78 @@
+package libs
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/wasilak/cloudpile/resources"
+)
+
+// itemKey identifies item among items of all collectors.
+func itemKey(item resources.Item) string {
+	return strings.Join([]string{item.Account, item.Region, item.Type, item.ID, item.ARN}, "|")
+}
+
+// resolveRecords appends to matched items the items DNS records among them
+// point to, e.g. load balancers, instances or other records. CNAME and alias
+// chains are followed through records of all items.
+func resolveRecords(matched, all []resources.Item) []resources.Item {
+	byName := map[string][]int{}
+	byAddress := map[string][]int{}
+
+	for i, item := range all {
+		if item.PrivateDNSName != "" {
+			name := strings.ToLower(item.PrivateDNSName)
+			byName[name] = append(byName[name], i)
+		}
+
+		// records point to addresses, they do not own them
+		if len(item.Targets) > 0 {
+			continue
+		}
+
+		for _, address := range itemAddresses(item) {
+			if address != "" {
+				byAddress[address] = append(byAddress[address], i)
+			}
+		}
+	}
+
+	seen := map[string]bool{}
+	queue := []resources.Item{}
+	for _, item := range matched {
+		seen[itemKey(item)] = true
+		if len(item.Targets) > 0 {
+			queue = append(queue, item)
+		}
+	}
+
+	for len(queue) > 0 {
+		record := queue[0]
+		queue = queue[1:]
+
+		for _, target := range record.Targets {
+			target = strings.ToLower(target)
+
+			found := slices.Concat(byName[target], byAddress[target])
+			// alias targets of load balancers are prefixed with dualstack.
+			if name, ok := strings.CutPrefix(target, "dualstack."); ok {
+				found = append(found, byName[name]...)
+			}
+
+			for _, i := range found {
+				key := itemKey(all[i])
+				if seen[key] {
+					continue
+				}
+				seen[key] = true
+
+				matched = append(matched, all[i])
+				if len(all[i].Targets) > 0 {
+					queue = append(queue, all[i])
+				}
+			}
+		}
+	}
+
+	return matched
+}
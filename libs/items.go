@@ -1,37 +1,138 @@
 package libs
 
 import (
-	"net"
-	"slices"
+	"context"
+	"fmt"
 	"sync"
 	"time"
 
 	"log/slog"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
-	"github.com/aws/aws-sdk-go-v2/service/autoscaling"
-	"github.com/aws/aws-sdk-go-v2/service/ec2"
-	"github.com/aws/aws-sdk-go-v2/service/elasticloadbalancingv2"
-	"github.com/aws/aws-sdk-go-v2/service/lambda"
+	"github.com/aws/aws-sdk-go-v2/service/resourcegroupstaggingapi"
+	"github.com/spf13/viper"
 	"github.com/wasilak/cloudpile/cache"
 	"github.com/wasilak/cloudpile/resources"
-	ec2Resource "github.com/wasilak/cloudpile/resources/ec2"
+
+	// register EC2 family collectors
+	_ "github.com/wasilak/cloudpile/resources/ec2"
+)
+
+// Result holds collected items together with the outcome of every collector
+// that contributed to them.
+type Result struct {
+	Items   []resources.Item   `json:"items"`
+	Sources []resources.Source `json:"sources"`
+}
+
+// RefreshMode tells Run when collectors are called instead of serving cache.
+type RefreshMode int
+
+const (
+	// FromCache serves cached entries, missing and expired entries are
+	// refreshed on request only in stale while revalidate mode.
+	FromCache RefreshMode = iota
+	// RefreshExpired collects entries which are missing, failed or about to expire.
+	RefreshExpired
+	// ForceRefresh collects all entries.
+	ForceRefresh
 )
 
-func Run(IDs []string, cacheInstance cache.Cache, forceRefresh bool) ([]resources.Item, error) {
+// job is a single collector run for one account, region and resource type.
+type job struct {
+	Resource resources.AWSResourceType
+	Source   resources.Source
+	Timeout  time.Duration
+	// TagSweep is set in tagging API mode for collectors with a TaggingType.
+	TagSweep *resources.TagSweep
+}
+
+// Filter narrows collection down to matching collectors, empty fields match all.
+type Filter struct {
+	AccountAlias string
+	Region       string
+	Type         string
+}
+
+func (f Filter) matchAccount(awsConfig AWSConfig) bool {
+	return f.AccountAlias == "" || f.AccountAlias == awsConfig.AccountAlias
+}
+
+func (f Filter) matchRegion(region string) bool {
+	return f.Region == "" || f.Region == region
+}
+
+func (f Filter) matchType(name string) bool {
+	return f.Type == "" || f.Type == name
+}
+
+// Run returns items matching query, nil query returns all items.
+func Run(ctx context.Context, query Query, cacheInstance cache.Cache, mode RefreshMode) (Result, error) {
+	return run(ctx, query, cacheInstance, mode, Filter{})
+}
+
+// Refresh collects entries of collectors matching filter into cache right away
+// and persists the cache.
+func Refresh(ctx context.Context, cacheInstance cache.Cache, filter Filter) (Result, error) {
+	result, err := run(ctx, nil, cacheInstance, ForceRefresh, filter)
+	if err != nil {
+		return result, err
+	}
+
+	if cacheInstance.Enabled {
+		err = cacheInstance.Cache.Flush()
+	}
+
+	return result, err
+}
+
+func run(ctx context.Context, query Query, cacheInstance cache.Cache, mode RefreshMode, filter Filter) (Result, error) {
 
-	chanItems := make(chan []resources.Item)
+	chanItems := make(chan cache.Entry)
 
 	var wg sync.WaitGroup
 
-	for _, awsConfig := range AWSConfigs {
+	for _, awsConfig := range Accounts(ctx) {
+		if !filter.matchAccount(awsConfig) {
+			continue
+		}
+
+		regions, err := accountRegions(ctx, awsConfig)
+		if err != nil {
+			slog.Debug(err.Error(), "awsConfig", awsConfig)
+			reportFailure(&wg, chanItems, awsConfig, allRegions, filter, err)
+			continue
+		}
+		awsConfig.Regions = regions
+
+		// buckets are listed once per account and collected in their regions
+		buckets := &resources.BucketListing{Timeout: collectorTimeout("s3")}
+		if len(regions) > 0 {
+			buckets.FallbackRegion = regions[0]
+		}
+
 		for _, region := range awsConfig.Regions {
+			if !filter.matchRegion(region) {
+				continue
+			}
 
-			awsConfigV2, err := newAWSV2Config(awsConfig, region)
+			awsConfigV2, err := awsConfigFor(ctx, awsConfig, region)
 			if err != nil {
 				slog.Debug(err.Error(), "awsConfig", awsConfig, "region", region)
+				reportFailure(&wg, chanItems, awsConfig, region, filter, err)
+			} else {
+				fetchItems(ctx, &wg, chanItems, region, awsConfigV2, awsConfig, buckets, cacheInstance, mode, filter)
+			}
+		}
+
+		// global collectors run once per account, with the first configured region
+		if len(awsConfig.Regions) > 0 && hasGlobalResources(awsConfig) && filter.matchRegion(resources.GlobalRegion) {
+			awsConfigV2, err := awsConfigFor(ctx, awsConfig, awsConfig.Regions[0])
+			if err != nil {
+				slog.Debug(err.Error(), "awsConfig", awsConfig, "region", resources.GlobalRegion)
+				reportFailure(&wg, chanItems, awsConfig, resources.GlobalRegion, filter, err)
 			} else {
-				fetchItems(&wg, chanItems, region, awsConfigV2, awsConfig, cacheInstance, forceRefresh)
+				fetchItems(ctx, &wg, chanItems, resources.GlobalRegion, awsConfigV2, awsConfig, buckets, cacheInstance, mode, filter)
 			}
 		}
 	}
@@ -41,245 +142,318 @@ func Run(IDs []string, cacheInstance cache.Cache, forceRefresh bool) ([]resource
 		close(chanItems)
 	}()
 
-	items := []resources.Item{}
-	for result := range chanItems {
-		items = append(items, result...)
+	result := Result{
+		Items:   []resources.Item{},
+		Sources: []resources.Source{},
 	}
-
-	if len(IDs) == 0 {
-		return items, nil
+	for c := range chanItems {
+		result.Items = append(result.Items, c.Items...)
+		result.Sources = append(result.Sources, c.Source)
 	}
 
-	filteredItems := filterItems(items, IDs)
+	if query != nil {
+		result.Items = resolveRecords(filterItems(result.Items, query), result.Items)
+	}
 
-	return filteredItems, nil
+	return result, nil
 }
 
-func fetchItems(wg *sync.WaitGroup, chanItems chan<- []resources.Item, region string, awsConfigV2 aws.Config, awsConfig AWSConfig, cacheInstance cache.Cache, forceRefresh bool) {
-	res := []resources.AWSResourceType{}
-	var (
-		accountID string
-		err       error
-	)
+// reportFailure sends an empty result with err for every resource type of an
+// account and region that could not be collected at all. With allRegions,
+// when regions of the account are unknown, every type is reported, global
+// ones under resources.GlobalRegion.
+func reportFailure(wg *sync.WaitGroup, chanItems chan<- cache.Entry, awsConfig AWSConfig, region string, filter Filter, err error) {
+	for _, name := range awsConfig.Resources {
+		collector, ok := resources.GetCollector(name)
+		if !ok || !filter.matchType(name) {
+			continue
+		}
+
+		region := region
+		if region != allRegions && !runsIn(collector, region) {
+			continue
+		}
+
+		if region == allRegions && collector.Global {
+			region = resources.GlobalRegion
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			chanItems <- cache.Entry{
+				Items: []resources.Item{},
+				Source: resources.Source{
+					AccountAlias: awsConfig.AccountAlias,
+					Region:       region,
+					Type:         name,
+					Error:        err.Error(),
+				},
+			}
+		}()
+	}
+}
 
-	accountID, err = getAccountId(awsConfigV2)
+func fetchItems(ctx context.Context, wg *sync.WaitGroup, chanItems chan<- cache.Entry, region string, awsConfigV2 aws.Config, awsConfig AWSConfig, buckets *resources.BucketListing, cacheInstance cache.Cache, mode RefreshMode, filter Filter) {
+	// without account ID cache keys of accounts would collide
+	accountID, err := accountIDFor(ctx, awsConfig, awsConfigV2)
 	if err != nil {
-		slog.Error(err.Error())
-		accountID = ""
+		slog.Error(err.Error(), "account", awsConfig.Name(), "region", region)
+		reportFailure(wg, chanItems, awsConfig, region, filter, err)
+		return
 	}
 
-	ec2Client := ec2.NewFromConfig(awsConfigV2)
+	tagSweep := newTagSweep(awsConfigV2, awsConfig)
 
-	// EC2 instances
-	if slices.Contains(awsConfig.Resources, "ec2") {
+	for _, name := range awsConfig.Resources {
+		collector, ok := resources.GetCollector(name)
+		if !ok || !runsIn(collector, region) || !filter.matchType(name) {
+			continue
+		}
 
-		res = append(res, &ec2Resource.EC2Instance{
-			Client: ec2Client,
-			BaseAWSResource: resources.BaseAWSResource{
-				AccountID:    accountID,
-				AccountAlias: awsConfig.AccountAlias,
-				Region:       region,
-				Type:         "ec2",
-			},
-		})
-	}
+		base := resources.BaseAWSResource{
+			AccountID:    accountID,
+			AccountAlias: awsConfig.AccountAlias,
+			Region:       region,
+			Type:         name,
+			MaxPageSize:  viper.GetInt32(fmt.Sprintf("collectors.%s.page_size", name)),
+			MaxItems:     viper.GetInt(fmt.Sprintf("collectors.%s.max_items", name)),
+			Buckets:      buckets,
+		}
 
-	// EC2 security groups
-	if slices.Contains(awsConfig.Resources, "sg") {
-		res = append(res, &ec2Resource.EC2Sg{
-			Client: ec2Client,
-			BaseAWSResource: resources.BaseAWSResource{
-				AccountID:    accountID,
-				AccountAlias: awsConfig.AccountAlias,
-				Region:       region,
-				Type:         "sg",
+		j := job{
+			Source: resources.Source{
+				Account:      base.AccountID,
+				AccountAlias: base.AccountAlias,
+				Region:       base.Region,
+				Type:         base.Type,
 			},
-		})
-	}
+			Timeout: collectorTimeout(name),
+		}
 
-	// Lambda functions
-	if slices.Contains(awsConfig.Resources, "lambda") {
+		if tagSweep != nil && collector.TaggingType != "" {
+			base.SkipTags = true
+			j.TagSweep = tagSweep
+		}
 
-		lambdaClient := lambda.NewFromConfig(awsConfigV2)
+		j.Resource = collector.New(awsConfigV2, base)
 
-		res = append(res, &resources.LambdaFunction{
-			Client: lambdaClient,
-			BaseAWSResource: resources.BaseAWSResource{
-				AccountID:    accountID,
-				AccountAlias: awsConfig.AccountAlias,
-				Region:       region,
-				Type:         "lambda",
-			},
-		})
+		wg.Add(1)
+		go describeItems(ctx, wg, chanItems, cacheInstance, mode, j)
 	}
+}
 
-	// EC2 load balancers
-	if slices.Contains(awsConfig.Resources, "elb") {
+// hasGlobalResources reports whether any of account resources is global.
+func hasGlobalResources(awsConfig AWSConfig) bool {
+	for _, name := range awsConfig.Resources {
+		if collector, ok := resources.GetCollector(name); ok && collector.Global {
+			return true
+		}
+	}
 
-		elbClient := elasticloadbalancingv2.NewFromConfig(awsConfigV2)
+	return false
+}
 
-		res = append(res, &ec2Resource.ELB{
-			Client: elbClient,
-			BaseAWSResource: resources.BaseAWSResource{
-				AccountID:    accountID,
-				AccountAlias: awsConfig.AccountAlias,
-				Region:       region,
-				Type:         "elb",
-			},
-		})
+// newTagSweep returns shared tag sweep for account collectors supporting the
+// Resource Groups Tagging API, or nil when collectors.tagging_api is disabled.
+func newTagSweep(awsConfigV2 aws.Config, awsConfig AWSConfig) *resources.TagSweep {
+	if !viper.GetBool("collectors.tagging_api") {
+		return nil
 	}
 
-	// EC2 autoscaling groups
-	if slices.Contains(awsConfig.Resources, "asg") {
+	resourceTypes := []string{}
+	for _, name := range awsConfig.Resources {
+		if collector, ok := resources.GetCollector(name); ok && collector.TaggingType != "" {
+			resourceTypes = append(resourceTypes, collector.TaggingType)
+		}
+	}
 
-		asgClient := autoscaling.NewFromConfig(awsConfigV2)
+	if len(resourceTypes) == 0 {
+		return nil
+	}
 
-		res = append(res, &ec2Resource.ASG{
-			Client: asgClient,
-			BaseAWSResource: resources.BaseAWSResource{
-				AccountID:    accountID,
-				AccountAlias: awsConfig.AccountAlias,
-				Region:       region,
-				Type:         "asg",
-			},
-		})
+	return &resources.TagSweep{
+		Client:        resourcegroupstaggingapi.NewFromConfig(awsConfigV2),
+		ResourceTypes: resourceTypes,
+		Timeout:       viper.GetDuration("collectors.timeout"),
 	}
+}
 
-	for _, itemsType := range res {
-		wg.Add(1)
-		go describeItems(wg, chanItems, cacheInstance, forceRefresh, itemsType)
+// collectorTimeout returns timeout configured for a resource type, falling back
+// to the common collectors timeout.
+func collectorTimeout(name string) time.Duration {
+	key := fmt.Sprintf("collectors.%s.timeout", name)
+	if viper.IsSet(key) {
+		return viper.GetDuration(key)
 	}
+
+	return viper.GetDuration("collectors.timeout")
 }
 
-func describeItems(wg *sync.WaitGroup, chanItems chan<- []resources.Item, cacheInstance cache.Cache, forceRefresh bool, res resources.AWSResourceType) {
-	defer wg.Done()
-	var result interface{}
-	var err error
+// collect runs a collector and records its outcome in job source.
+func collect(ctx context.Context, j job) cache.Entry {
+	res, source := j.Resource, j.Source
 
-	items := []resources.Item{}
+	release, err := acquireWorker(ctx)
+	if err != nil {
+		source.Error = err.Error()
+		return cache.Entry{Items: []resources.Item{}, Source: source}
+	}
+	defer release()
 
-	if cacheInstance.Enabled {
+	// waiting for a worker does not count against the collector timeout
+	if j.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, j.Timeout)
+		defer cancel()
+	}
+
+	start := time.Now()
 
-		var found bool
+	items, err := res.Get(ctx)
+	if err != nil {
+		slog.Error(err.Error(), "cache_key", res.GetCacheKey())
+		source.Error = err.Error()
+	}
 
-		result, found = cacheInstance.Cache.Get(res.GetCacheKey())
+	if items == nil {
+		items = []resources.Item{}
+	}
 
-		if !forceRefresh && !found {
-			slog.Debug("Cache not yet initialized", "cache_key", res.GetCacheKey(), "forceRefresh", forceRefresh)
+	if err == nil && j.TagSweep != nil {
+		if err = j.TagSweep.Apply(ctx, items); err != nil {
+			slog.Error(err.Error(), "cache_key", res.GetCacheKey())
+			source.Error = err.Error()
 		}
+	}
 
-		if found {
-			slog.Debug("Cache hit", "cache_key", res.GetCacheKey(), "forceRefresh", forceRefresh)
-			items = result.([]resources.Item)
-		} else {
-			slog.Debug("Cache miss", "cache_key", res.GetCacheKey(), "forceRefresh", forceRefresh)
+	// items without a resource name are known by their Name tag
+	for i := range items {
+		if items[i].Name == "" {
+			items[i].Name = items[i].TagValue("Name")
 		}
+	}
 
-		if forceRefresh {
-			items, err = res.Get()
-			if err != nil {
-				slog.Error(err.Error())
-			}
+	source.Duration = time.Since(start)
+	source.ItemCount = len(items)
+
+	now := time.Now()
+	return cache.Entry{
+		Items:       items,
+		Source:      source,
+		UpdatedAt:   now,
+		AttemptedAt: now,
+	}
+}
 
-			// set a value with a cost of 1
-			cacheInstance.Cache.Set(res.GetCacheKey(), items, 1)
+func describeItems(ctx context.Context, wg *sync.WaitGroup, chanItems chan<- cache.Entry, cacheInstance cache.Cache, mode RefreshMode, j job) {
+	defer wg.Done()
 
-			// wait for value to pass through buffers
-			cacheInstance.Cache.Wait()
+	if !cacheInstance.Enabled {
+		chanItems <- collect(ctx, j)
+		return
+	}
 
-			nextUpdate := time.Now().Add(cacheInstance.TTL)
+	key := j.Resource.GetCacheKey()
+	entry, found := cacheInstance.Cache.Get(key)
 
-			slog.Debug("Cache refresh done", "cache_key", res.GetCacheKey(), "forceRefresh", forceRefresh, "next_in", cache.CacheInstance.TTL, "next_time", nextUpdate)
-		}
+	// failed entries are not worth keeping until TTL passes
+	expired := found && (entry.Source.Error != "" || cacheInstance.Expired(entry, j.Source.Type, time.Now()))
 
-	} else {
-		items, err = res.Get()
-		if err != nil {
-			slog.Error(err.Error())
+	switch {
+	case mode == ForceRefresh:
+		entry = refreshEntry(ctx, cacheInstance, j)
+
+	case mode == RefreshExpired:
+		// entries expiring before the next check are refreshed now
+		if !found || entry.Source.Error != "" || cacheInstance.Expired(entry, j.Source.Type, time.Now().Add(cacheInstance.MinTTL()/2)) {
+			entry = refreshEntry(ctx, cacheInstance, j)
 		}
+
+	case !found && cacheInstance.StaleWhileRevalidate:
+		slog.Debug("Cache miss, refreshing on request", "cache_key", key)
+		entry = refreshEntry(ctx, cacheInstance, j)
+
+	case !found:
+		slog.Debug("Cache not yet initialized", "cache_key", key)
+		j.Source.Error = "cache not yet initialized"
+		entry = cache.Entry{Items: []resources.Item{}, Source: j.Source}
+
+	case expired && cacheInstance.StaleWhileRevalidate:
+		slog.Debug("Cache stale, revalidating in background", "cache_key", key, "updated_at", entry.UpdatedAt)
+		revalidate(ctx, cacheInstance, j)
+
+	default:
+		slog.Debug("Cache hit", "cache_key", key, "updated_at", entry.UpdatedAt)
 	}
 
-	chanItems <- items
+	chanItems <- entry
 }
 
-func filterItems(items []resources.Item, IDs []string) []resources.Item {
-	var filteredItems []resources.Item
-	var resourceIDs []string
-	var resourceIPs []string
-	var resourceTags []map[string]string
-
-	for _, id := range IDs {
-		// tags
-		tags := getTagsFromString(id)
-		if len(tags) > 0 {
-			resourceTags = append(resourceTags, tags)
-		}
-
-		// IP is kinda special as it is not string, everything else can be matched in loop below
-		if net.ParseIP(id) != nil {
-			resourceIPs = append(resourceIPs, id)
-		} else {
-			resourceIDs = append(resourceIDs, id)
-		}
+// refreshEntry collects job items and stores them in cache.
+func refreshEntry(ctx context.Context, cacheInstance cache.Cache, j job) cache.Entry {
+	key := j.Resource.GetCacheKey()
+	entry := collect(ctx, j)
 
+	// keep previous data when collection was interrupted by shutdown
+	if ctx.Err() != nil {
+		return entry
 	}
 
-	if len(IDs) != 0 && len(resourceIDs) == 0 && len(resourceIPs) == 0 {
-		return []resources.Item{}
+	// failed collection keeps previous items, the error makes it retried on next check
+	if entry.Source.Error != "" {
+		if previous, found := cacheInstance.Cache.Get(key); found {
+			previous.Source.Error = entry.Source.Error
+			previous.Source.Duration = entry.Source.Duration
+			previous.AttemptedAt = entry.AttemptedAt
+			entry = previous
+		}
 	}
 
-	for _, item := range items {
+	cacheInstance.Set(key, j.Source.Type, entry)
 
-		hit := false
+	ttl := cacheInstance.TTLFor(j.Source.Type)
+	slog.Debug("Cache refresh done", "cache_key", key, "next_in", ttl, "next_time", entry.UpdatedAt.Add(ttl))
 
-		for _, id := range resourceIDs {
-			if item.ID == id || item.PrivateDNSName == id {
-				hit = true
-			}
+	return entry
+}
 
-			if hit {
-				break
-			}
-		}
+var (
+	revalidatingMu sync.Mutex
+	revalidating   = map[string]bool{}
+)
 
-		for _, id := range resourceIDs {
-			if item.ARN == id || item.PrivateDNSName == id {
-				hit = true
-			}
+// revalidate refreshes job entry in background, unless it is already being refreshed.
+// Refresh outlives the request that triggered it.
+func revalidate(ctx context.Context, cacheInstance cache.Cache, j job) {
+	key := j.Resource.GetCacheKey()
 
-			if hit {
-				break
-			}
-		}
+	revalidatingMu.Lock()
+	defer revalidatingMu.Unlock()
 
-		for _, ip := range resourceIPs {
-			if item.IP == ip {
-				hit = true
-			}
+	if revalidating[key] {
+		return
+	}
+	revalidating[key] = true
 
-			if hit {
-				break
-			}
-		}
+	go func() {
+		defer func() {
+			revalidatingMu.Lock()
+			delete(revalidating, key)
+			revalidatingMu.Unlock()
+		}()
 
-		tagHit := 0
-		for _, v := range resourceTags {
-			for _, itemTag := range item.Tags {
-				if itemTag.Key == v["name"] && itemTag.Value == v["value"] {
-					tagHit++
-				}
-			}
-		}
+		refreshEntry(context.WithoutCancel(ctx), cacheInstance, j)
+	}()
+}
 
-		if len(resourceTags) > 0 && tagHit == len(resourceTags) {
-			hit = true
-		}
+func filterItems(items []resources.Item, query Query) []resources.Item {
+	filteredItems := []resources.Item{}
 
-		if !hit {
-			continue
+	for _, item := range items {
+		if query.Match(item) {
+			filteredItems = append(filteredItems, item)
 		}
-
-		filteredItems = append(filteredItems, item)
 	}
 
 	return filteredItems
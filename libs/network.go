@@ -0,0 +1,139 @@
+package libs
+
+import (
+	"cmp"
+	"fmt"
+	"net/netip"
+	"slices"
+	"strings"
+
+	"github.com/wasilak/cloudpile/resources"
+)
+
+// ipRangeQuery matches items with any address between from and to, inclusive,
+// and networks with a CIDR overlapping the range, e.g. the subnet of an address.
+type ipRangeQuery struct {
+	from, to netip.Addr
+}
+
+func (q ipRangeQuery) Match(item resources.Item) bool {
+	for _, address := range itemAddresses(item) {
+		addr, err := netip.ParseAddr(address)
+		if err != nil {
+			continue
+		}
+
+		addr = addr.Unmap()
+		if addr.BitLen() == q.from.BitLen() && q.from.Compare(addr) <= 0 && addr.Compare(q.to) <= 0 {
+			return true
+		}
+	}
+
+	for _, cidr := range item.CIDRs {
+		prefix, err := netip.ParsePrefix(cidr)
+		if err != nil {
+			continue
+		}
+
+		first, last := prefix.Masked().Addr(), lastAddr(prefix)
+		if first.BitLen() == q.from.BitLen() && first.Compare(q.to) <= 0 && q.from.Compare(last) <= 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+// itemAddresses returns all IP addresses of item.
+func itemAddresses(item resources.Item) []string {
+	addresses := []string{item.IP}
+	for _, address := range item.Addresses {
+		addresses = append(addresses, address.IP)
+	}
+
+	return addresses
+}
+
+// lastAddr returns the highest address of prefix.
+func lastAddr(prefix netip.Prefix) netip.Addr {
+	bytes := prefix.Masked().Addr().AsSlice()
+	for bit := prefix.Bits(); bit < len(bytes)*8; bit++ {
+		bytes[bit/8] |= 1 << (7 - bit%8)
+	}
+
+	addr, _ := netip.AddrFromSlice(bytes)
+	return addr
+}
+
+// ParseIPRange parses a single address, CIDR (10.20.0.0/16) or address range
+// (10.1.2.3-10.1.2.40) into query matching items with an address in it.
+func ParseIPRange(value string) (Query, error) {
+	if prefix, err := netip.ParsePrefix(value); err == nil {
+		return ipRangeQuery{from: prefix.Masked().Addr(), to: lastAddr(prefix)}, nil
+	}
+
+	if from, to, isRange := strings.Cut(value, "-"); isRange {
+		fromAddr, err := netip.ParseAddr(from)
+		if err != nil {
+			return nil, err
+		}
+
+		toAddr, err := netip.ParseAddr(to)
+		if err != nil {
+			return nil, err
+		}
+
+		fromAddr, toAddr = fromAddr.Unmap(), toAddr.Unmap()
+		if fromAddr.BitLen() != toAddr.BitLen() || fromAddr.Compare(toAddr) > 0 {
+			return nil, fmt.Errorf("invalid IP range %q", value)
+		}
+
+		return ipRangeQuery{from: fromAddr, to: toAddr}, nil
+	}
+
+	addr, err := netip.ParseAddr(value)
+	if err != nil {
+		return nil, err
+	}
+
+	addr = addr.Unmap()
+	return ipRangeQuery{from: addr, to: addr}, nil
+}
+
+// NetworkGroup holds items of a single account and VPC.
+type NetworkGroup struct {
+	Account      string           `json:"account"`
+	AccountAlias string           `json:"accountAlias"`
+	VpcID        string           `json:"vpcId"`
+	Items        []resources.Item `json:"items"`
+}
+
+// GroupByNetwork groups items by account and VPC, sorted by account alias and VPC.
+func GroupByNetwork(items []resources.Item) []NetworkGroup {
+	groups := []NetworkGroup{}
+	index := map[[2]string]int{}
+
+	for _, item := range items {
+		key := [2]string{item.Account, item.VpcID}
+
+		i, ok := index[key]
+		if !ok {
+			i = len(groups)
+			index[key] = i
+			groups = append(groups, NetworkGroup{
+				Account:      item.Account,
+				AccountAlias: item.AccountAlias,
+				VpcID:        item.VpcID,
+				Items:        []resources.Item{},
+			})
+		}
+
+		groups[i].Items = append(groups[i].Items, item)
+	}
+
+	slices.SortFunc(groups, func(a, b NetworkGroup) int {
+		return cmp.Or(cmp.Compare(a.AccountAlias, b.AccountAlias), cmp.Compare(a.Account, b.Account), cmp.Compare(a.VpcID, b.VpcID))
+	})
+
+	return groups
+}
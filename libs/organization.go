@@ -0,0 +1,333 @@
+package libs
+
+import (
+	"context"
+	"log/slog"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/organizations"
+	"github.com/aws/aws-sdk-go-v2/service/organizations/types"
+	"github.com/wasilak/cloudpile/cache"
+)
+
+// defaultRoleTemplate is the role assumed into member accounts of an organization.
+const defaultRoleTemplate = "arn:aws:iam::{account_id}:role/cloudpile-reader"
+
+// AccountSelector selects organization member accounts by ID, organizational
+// unit (including nested ones) or tag. An account matching any of them is selected.
+type AccountSelector struct {
+	Accounts []string          `mapstructure:"accounts"`
+	OUs      []string          `mapstructure:"ous"`
+	Tags     map[string]string `mapstructure:"tags"`
+}
+
+func (s AccountSelector) empty() bool {
+	return len(s.Accounts) == 0 && len(s.OUs) == 0 && len(s.Tags) == 0
+}
+
+func (s AccountSelector) match(account orgAccount) bool {
+	if slices.Contains(s.Accounts, account.ID) {
+		return true
+	}
+
+	for _, ou := range s.OUs {
+		if slices.Contains(account.OUs, ou) {
+			return true
+		}
+	}
+
+	for key, value := range s.Tags {
+		if tagValue, ok := account.Tags[key]; ok && wildcard(value).MatchString(tagValue) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// orgAccount is an active member account of an organization.
+type orgAccount struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	// OUs holds IDs of all organizational units the account is nested in.
+	OUs  []string          `json:"ous,omitempty"`
+	Tags map[string]string `json:"tags,omitempty"`
+}
+
+var (
+	discoveredMu sync.Mutex
+	// discovered holds member accounts of organization entries, by index in AWSConfigs.
+	discovered = map[int][]orgAccount{}
+	// discoveryFailed holds time of the last failed discovery of organization entries.
+	discoveryFailed = map[int]time.Time{}
+)
+
+// discoveryRetry is how long requests wait before retrying a failed discovery,
+// the cache runner retries on every tick regardless.
+const discoveryRetry = time.Minute
+
+// Accounts returns configured accounts, with organization entries expanded to
+// their member accounts. Organizations not discovered yet are discovered now.
+func Accounts(ctx context.Context) []AWSConfig {
+	accounts := []AWSConfig{}
+
+	for i, awsConfig := range AWSConfigs {
+		if awsConfig.Type != "organization" {
+			accounts = append(accounts, awsConfig)
+			continue
+		}
+
+		discoveredMu.Lock()
+		members, ok := discovered[i]
+		failedAt, failed := discoveryFailed[i]
+		discoveredMu.Unlock()
+
+		if !ok || (failed && time.Since(failedAt) > discoveryRetry) {
+			members = discover(ctx, i, awsConfig)
+		}
+
+		accounts = append(accounts, memberConfigs(awsConfig, members)...)
+	}
+
+	return accounts
+}
+
+// DiscoverAccounts refreshes member accounts of all organization entries and
+// regions of accounts collecting all regions.
+func DiscoverAccounts(ctx context.Context) {
+	for i, awsConfig := range AWSConfigs {
+		if awsConfig.Type == "organization" {
+			discover(ctx, i, awsConfig)
+		}
+	}
+
+	rediscoverRegions(ctx)
+}
+
+// discoveryKey is the key discovered accounts and regions are shared under.
+const discoveryKey = "discovery"
+
+// sharedDiscovery holds discovered member accounts and enabled regions, by
+// account config key, shared by the refreshing replica with the others.
+type sharedDiscovery struct {
+	Accounts map[string][]orgAccount `json:"accounts"`
+	Regions  map[string][]string     `json:"regions"`
+}
+
+// shareDiscovery saves discovered accounts and regions to store.
+func shareDiscovery(ctx context.Context, store cache.Store) error {
+	shared := sharedDiscovery{Accounts: map[string][]orgAccount{}}
+
+	discoveredMu.Lock()
+	for i, members := range discovered {
+		shared.Accounts[AWSConfigs[i].key()] = members
+	}
+	discoveredMu.Unlock()
+
+	regionsMu.Lock()
+	shared.Regions = maps.Clone(enabledRegions)
+	regionsMu.Unlock()
+
+	return store.Save(ctx, discoveryKey, shared)
+}
+
+// loadDiscovery replaces discovered accounts and regions with those shared
+// in store, if any.
+func loadDiscovery(ctx context.Context, store cache.Store) error {
+	var shared sharedDiscovery
+	found, err := store.Load(ctx, discoveryKey, &shared)
+	if err != nil || !found {
+		return err
+	}
+
+	discoveredMu.Lock()
+	for i, awsConfig := range AWSConfigs {
+		if members, ok := shared.Accounts[awsConfig.key()]; ok {
+			discovered[i] = members
+			delete(discoveryFailed, i)
+		}
+	}
+	discoveredMu.Unlock()
+
+	regionsMu.Lock()
+	maps.Copy(enabledRegions, shared.Regions)
+	regionsMu.Unlock()
+
+	return nil
+}
+
+// discover lists member accounts of organization entry i and stores them.
+// On failure previously discovered accounts are kept.
+func discover(ctx context.Context, i int, awsConfig AWSConfig) []orgAccount {
+	members, err := discoverAccounts(ctx, awsConfig)
+
+	discoveredMu.Lock()
+	defer discoveredMu.Unlock()
+
+	if err != nil {
+		slog.Error("Organization account discovery failed", "organization", awsConfig.Name(), "error", err)
+
+		// previous members are kept, failure is stored so requests don't retry it
+		if _, ok := discovered[i]; !ok {
+			discovered[i] = []orgAccount{}
+		}
+		discoveryFailed[i] = time.Now()
+
+		return discovered[i]
+	}
+
+	slog.Debug("Organization accounts discovered", "organization", awsConfig.Name(), "accounts", len(members))
+	discovered[i] = members
+	delete(discoveryFailed, i)
+
+	return members
+}
+
+// discoverAccounts lists active accounts of organization with its management
+// account credentials and returns those selected by Include and Exclude.
+func discoverAccounts(ctx context.Context, awsConfig AWSConfig) ([]orgAccount, error) {
+	cfg, err := awsConfigFor(ctx, awsConfig.managementConfig(), apiRegion(awsConfig.Regions))
+	if err != nil {
+		return nil, err
+	}
+
+	client := organizations.NewFromConfig(cfg)
+
+	needsOUs := len(awsConfig.Include.OUs) > 0 || len(awsConfig.Exclude.OUs) > 0
+	needsTags := len(awsConfig.Include.Tags) > 0 || len(awsConfig.Exclude.Tags) > 0
+
+	members := []orgAccount{}
+
+	paginator := organizations.NewListAccountsPaginator(client, &organizations.ListAccountsInput{})
+	for paginator.HasMorePages() {
+		result, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, account := range result.Accounts {
+			if !accountActive(account) {
+				continue
+			}
+
+			member := orgAccount{
+				ID:   aws.ToString(account.Id),
+				Name: aws.ToString(account.Name),
+			}
+
+			if needsOUs {
+				if member.OUs, err = accountOUs(ctx, client, member.ID); err != nil {
+					return nil, err
+				}
+			}
+
+			if needsTags {
+				if member.Tags, err = accountTags(ctx, client, member.ID); err != nil {
+					return nil, err
+				}
+			}
+
+			if !awsConfig.Include.empty() && !awsConfig.Include.match(member) {
+				continue
+			}
+
+			if awsConfig.Exclude.match(member) {
+				continue
+			}
+
+			members = append(members, member)
+		}
+	}
+
+	return members, nil
+}
+
+// memberConfigs returns configs of organization member accounts, assuming
+// RoleTemplate into them.
+func memberConfigs(awsConfig AWSConfig, members []orgAccount) []AWSConfig {
+	roleTemplate := awsConfig.RoleTemplate
+	if roleTemplate == "" {
+		roleTemplate = defaultRoleTemplate
+	}
+
+	// member roles are assumed with management credentials, through the
+	// hub role and the management iam_role_arn when set
+	management := awsConfig.managementConfig()
+
+	configs := []AWSConfig{}
+	for _, member := range members {
+		configs = append(configs, AWSConfig{
+			Type:              management.Type,
+			Profile:           management.Profile,
+			HubRoleARN:        management.HubRoleARN,
+			managementRoleARN: management.IAMRoleARN,
+			IAMRoleARN:        strings.ReplaceAll(roleTemplate, "{account_id}", member.ID),
+			ExternalID:        management.ExternalID,
+			SessionName:       management.SessionName,
+			SessionDuration:   management.SessionDuration,
+			AccountAlias:      member.Name,
+			Regions:           awsConfig.Regions,
+			ExcludeRegions:    awsConfig.ExcludeRegions,
+			Resources:         awsConfig.Resources,
+		})
+	}
+
+	return configs
+}
+
+// accountActive reports whether account is active, State replaces deprecated Status.
+func accountActive(account types.Account) bool {
+	if account.State != "" {
+		return account.State == types.AccountStateActive
+	}
+
+	return account.Status == types.AccountStatusActive
+}
+
+// accountOUs returns IDs of organizational units account is nested in, from
+// its parent up to the root.
+func accountOUs(ctx context.Context, client *organizations.Client, accountID string) ([]string, error) {
+	ous := []string{}
+	childID := accountID
+
+	for {
+		result, err := client.ListParents(ctx, &organizations.ListParentsInput{
+			ChildId: aws.String(childID),
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		if len(result.Parents) == 0 || result.Parents[0].Type != types.ParentTypeOrganizationalUnit {
+			return ous, nil
+		}
+
+		childID = aws.ToString(result.Parents[0].Id)
+		ous = append(ous, childID)
+	}
+}
+
+func accountTags(ctx context.Context, client *organizations.Client, accountID string) (map[string]string, error) {
+	tags := map[string]string{}
+
+	paginator := organizations.NewListTagsForResourcePaginator(client, &organizations.ListTagsForResourceInput{
+		ResourceId: aws.String(accountID),
+	})
+	for paginator.HasMorePages() {
+		result, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, tag := range result.Tags {
+			tags[aws.ToString(tag.Key)] = aws.ToString(tag.Value)
+		}
+	}
+
+	return tags, nil
+}
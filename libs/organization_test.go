@@ -0,0 +1,85 @@
+package libs
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"testing"
+)
+
+// memoryStore is a cache.Store of a single replica.
+type memoryStore map[string][]byte
+
+func (s memoryStore) Save(ctx context.Context, key string, value any) error {
+	data, err := json.Marshal(value)
+	s[key] = data
+	return err
+}
+
+func (s memoryStore) Load(ctx context.Context, key string, value any) (bool, error) {
+	data, ok := s[key]
+	if !ok {
+		return false, nil
+	}
+
+	return true, json.Unmarshal(data, value)
+}
+
+func TestSharedDiscovery(t *testing.T) {
+	ctx := context.Background()
+	store := memoryStore{}
+
+	organization := AWSConfig{
+		Type:       "organization",
+		Profile:    "management",
+		IAMRoleARN: "arn:aws:iam::111111111111:role/org-reader",
+		Regions:    []string{"all"},
+		Resources:  []string{"ec2"},
+	}
+	AWSConfigs = []AWSConfig{organization}
+	t.Cleanup(func() {
+		AWSConfigs = nil
+		discovered = map[int][]orgAccount{}
+		enabledRegions = map[string][]string{}
+	})
+
+	// follower without shared state keeps what it has
+	if err := loadDiscovery(ctx, store); err != nil {
+		t.Fatal(err)
+	}
+	if len(discovered) != 0 {
+		t.Fatalf("discovered = %v, want none", discovered)
+	}
+
+	// leader shares what it discovered
+	discovered = map[int][]orgAccount{0: {{ID: "222222222222", Name: "prod"}}}
+	member := memberConfigs(organization, discovered[0])[0]
+	enabledRegions = map[string][]string{member.key(): {"eu-central-1", "us-east-1"}}
+
+	if err := shareDiscovery(ctx, store); err != nil {
+		t.Fatal(err)
+	}
+
+	discovered = map[int][]orgAccount{}
+	enabledRegions = map[string][]string{}
+
+	if err := loadDiscovery(ctx, store); err != nil {
+		t.Fatal(err)
+	}
+
+	accounts := Accounts(ctx)
+	if len(accounts) != 1 || accounts[0].AccountAlias != "prod" || accounts[0].IAMRoleARN != "arn:aws:iam::222222222222:role/cloudpile-reader" {
+		t.Fatalf("Accounts = %+v, want prod member", accounts)
+	}
+	if accounts[0].managementRoleARN != organization.IAMRoleARN {
+		t.Errorf("member management role = %q, want %q", accounts[0].managementRoleARN, organization.IAMRoleARN)
+	}
+
+	regions, err := accountRegions(ctx, accounts[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(regions, []string{"eu-central-1", "us-east-1"}) {
+		t.Errorf("accountRegions = %v, want shared regions", regions)
+	}
+}
@@ -0,0 +1,400 @@
+package libs
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/wasilak/cloudpile/resources"
+)
+
+// Query is a parsed search expression evaluated against items.
+//
+// Terms are either bare values, matched against IDs, ARNs, names, DNS names,
+// IPs and key=value tags, or qualified with a field, e.g. type:ec2,
+// account:prod, name:api-*, region:eu-*, vpc:vpc-0abc*, subnet:subnet-0abc*,
+// tag:Team=payments, ip:10.20.0.0/16, ip:10.1.2.3-10.1.2.40. Values may use
+// * and ? wildcards and be double quoted, quoted AND, OR and NOT are plain values. Terms are joined
+// with AND (implicit), OR (also "|" and ",") and negated with NOT (also "-"
+// and "!"), parentheses group them. Matching is case insensitive.
+type Query interface {
+	Match(item resources.Item) bool
+}
+
+// QueryError points at the token which made parsing fail.
+type QueryError struct {
+	Position int    `json:"position"`
+	Token    string `json:"token"`
+	Message  string `json:"message"`
+}
+
+func (e *QueryError) Error() string {
+	if e.Token == "" {
+		return fmt.Sprintf("%s at position %d", e.Message, e.Position)
+	}
+
+	return fmt.Sprintf("%s %q at position %d", e.Message, e.Token, e.Position)
+}
+
+// queryFields maps field names to functions returning item values the field matches.
+var queryFields = map[string]func(item resources.Item) []string{
+	"id":   func(item resources.Item) []string { return []string{item.ID} },
+	"arn":  func(item resources.Item) []string { return []string{item.ARN} },
+	"name": func(item resources.Item) []string { return []string{item.Name} },
+	"account": func(item resources.Item) []string {
+		return []string{item.Account, item.AccountAlias}
+	},
+	"region": func(item resources.Item) []string { return []string{item.Region} },
+	"dns":    func(item resources.Item) []string { return []string{item.PrivateDNSName} },
+	"ip":     itemAddresses,
+	"vpc":    func(item resources.Item) []string { return []string{item.VpcID} },
+	"subnet": func(item resources.Item) []string { return []string{item.SubnetID} },
+}
+
+// bareFields are matched by terms without a field.
+var bareFields = []string{"id", "arn", "name", "dns", "ip"}
+
+type andQuery struct{ left, right Query }
+
+func (q andQuery) Match(item resources.Item) bool {
+	return q.left.Match(item) && q.right.Match(item)
+}
+
+type orQuery struct{ left, right Query }
+
+func (q orQuery) Match(item resources.Item) bool {
+	return q.left.Match(item) || q.right.Match(item)
+}
+
+type notQuery struct{ query Query }
+
+func (q notQuery) Match(item resources.Item) bool {
+	return !q.query.Match(item)
+}
+
+// fieldQuery matches one field of item against a wildcard pattern.
+type fieldQuery struct {
+	field   string
+	pattern *regexp.Regexp
+}
+
+func (q fieldQuery) Match(item resources.Item) bool {
+	for _, value := range queryFields[q.field](item) {
+		if value != "" && q.pattern.MatchString(value) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// typeQuery matches item type by its display type or by collector name.
+type typeQuery struct {
+	pattern     *regexp.Regexp
+	displayType string
+}
+
+func (q typeQuery) Match(item resources.Item) bool {
+	if q.displayType != "" && strings.HasPrefix(item.Type, q.displayType) {
+		return true
+	}
+
+	return q.pattern.MatchString(item.Type)
+}
+
+// tagQuery matches items having tag key, and value if given.
+type tagQuery struct {
+	key, value *regexp.Regexp
+}
+
+func (q tagQuery) Match(item resources.Item) bool {
+	for _, tag := range item.Tags {
+		if q.key.MatchString(tag.Key) && (q.value == nil || q.value.MatchString(tag.Value)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// wildcard compiles value with * and ? wildcards to a case insensitive regexp.
+func wildcard(value string) *regexp.Regexp {
+	pattern := regexp.QuoteMeta(value)
+	pattern = strings.ReplaceAll(pattern, `\*`, ".*")
+	pattern = strings.ReplaceAll(pattern, `\?`, ".")
+
+	return regexp.MustCompile("(?i)^" + pattern + "$")
+}
+
+type tokenKind int
+
+const (
+	tokenTerm tokenKind = iota
+	tokenAnd
+	tokenOr
+	tokenNot
+	tokenOpen
+	tokenClose
+	tokenEnd
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+func tokenize(input string) ([]token, error) {
+	tokens := []token{}
+	runes := []rune(input)
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(':
+			tokens = append(tokens, token{kind: tokenOpen, text: "(", pos: i})
+			i++
+		case r == ')':
+			tokens = append(tokens, token{kind: tokenClose, text: ")", pos: i})
+			i++
+		case r == '|' || r == ',':
+			tokens = append(tokens, token{kind: tokenOr, text: string(r), pos: i})
+			i++
+		case r == '&':
+			tokens = append(tokens, token{kind: tokenAnd, text: "&", pos: i})
+			i++
+		case r == '-' || r == '!':
+			tokens = append(tokens, token{kind: tokenNot, text: string(r), pos: i})
+			i++
+		default:
+			start := i
+			quoted := false
+			var text strings.Builder
+
+			for i < len(runes) && !unicode.IsSpace(runes[i]) && !strings.ContainsRune("()|,&", runes[i]) {
+				if runes[i] != '"' {
+					text.WriteRune(runes[i])
+					i++
+					continue
+				}
+
+				end := i + 1
+				for end < len(runes) && runes[end] != '"' {
+					end++
+				}
+				if end == len(runes) {
+					return nil, &QueryError{Position: i, Token: string(runes[i:]), Message: "unterminated quote"}
+				}
+
+				text.WriteString(string(runes[i+1 : end]))
+				quoted = true
+				i = end + 1
+			}
+
+			kind := tokenTerm
+			if !quoted {
+				switch strings.ToUpper(text.String()) {
+				case "AND":
+					kind = tokenAnd
+				case "OR":
+					kind = tokenOr
+				case "NOT":
+					kind = tokenNot
+				}
+			}
+
+			tokens = append(tokens, token{kind: kind, text: text.String(), pos: start})
+		}
+	}
+
+	return append(tokens, token{kind: tokenEnd, pos: len(runes)}), nil
+}
+
+type queryParser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *queryParser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *queryParser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEnd {
+		p.pos++
+	}
+	return t
+}
+
+// or = and { OR and }
+func (p *queryParser) parseOr() (Query, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.peek().kind == tokenOr {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = orQuery{left, right}
+	}
+
+	return left, nil
+}
+
+// and = unary { [AND] unary }
+func (p *queryParser) parseAnd() (Query, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		switch p.peek().kind {
+		case tokenAnd:
+			p.next()
+		case tokenTerm, tokenNot, tokenOpen:
+		default:
+			return left, nil
+		}
+
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = andQuery{left, right}
+	}
+}
+
+// unary = NOT unary | "(" or ")" | term
+func (p *queryParser) parseUnary() (Query, error) {
+	t := p.next()
+
+	switch t.kind {
+	case tokenNot:
+		query, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return notQuery{query}, nil
+
+	case tokenOpen:
+		query, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if closing := p.next(); closing.kind != tokenClose {
+			return nil, &QueryError{Position: t.pos, Token: t.text, Message: "unclosed parenthesis"}
+		}
+		return query, nil
+
+	case tokenTerm:
+		return parseTerm(t)
+
+	case tokenEnd:
+		return nil, &QueryError{Position: t.pos, Message: "unexpected end of query"}
+
+	default:
+		return nil, &QueryError{Position: t.pos, Token: t.text, Message: "unexpected"}
+	}
+}
+
+func parseTerm(t token) (Query, error) {
+	field, value, qualified := strings.Cut(t.text, ":")
+	field = strings.ToLower(field)
+
+	// only known fields qualify a term, so IPv6 addresses and ARNs stay bare
+	_, known := queryFields[field]
+	known = known || field == "type" || field == "tag"
+	if !qualified || !known || strings.HasPrefix(t.text, "arn:aws") {
+		return bareTerm(t.text), nil
+	}
+
+	if value == "" {
+		return nil, &QueryError{Position: t.pos, Token: t.text, Message: "missing value of field"}
+	}
+
+	switch field {
+	case "type":
+		query := typeQuery{pattern: wildcard(value)}
+		if collector, ok := resources.GetCollector(strings.ToLower(value)); ok {
+			query.displayType = collector.DisplayType
+		}
+		return query, nil
+
+	case "ip":
+		if query, err := ParseIPRange(value); err == nil {
+			return query, nil
+		}
+
+	case "tag":
+		key, tagValue, hasValue := strings.Cut(value, "=")
+		query := tagQuery{key: wildcard(key)}
+		if hasValue {
+			query.value = wildcard(tagValue)
+		}
+		return query, nil
+	}
+
+	return fieldQuery{field: field, pattern: wildcard(value)}, nil
+}
+
+// bareTerm matches value against all bare fields, key=value is matched as a
+// tag, addresses, CIDRs and address ranges are matched against item addresses.
+func bareTerm(value string) Query {
+	if query, err := ParseIPRange(value); err == nil {
+		return query
+	}
+
+	var query Query
+
+	for _, field := range bareFields {
+		var fq Query = fieldQuery{field: field, pattern: wildcard(value)}
+		if query == nil {
+			query = fq
+		} else {
+			query = orQuery{query, fq}
+		}
+	}
+
+	tags := getTagsFromString(value)
+	if len(tags) > 0 {
+		query = orQuery{query, tagQuery{key: wildcard(tags["name"]), value: wildcard(tags["value"])}}
+	}
+
+	return query
+}
+
+// ParseQuery parses search expression, empty expression returns nil query
+// matching everything.
+func ParseQuery(input string) (Query, error) {
+	tokens, err := tokenize(input)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(tokens) == 1 {
+		return nil, nil
+	}
+
+	p := &queryParser{tokens: tokens}
+
+	query, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+
+	if t := p.peek(); t.kind != tokenEnd {
+		return nil, &QueryError{Position: t.pos, Token: t.text, Message: "unexpected"}
+	}
+
+	return query, nil
+}
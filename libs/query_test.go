@@ -0,0 +1,214 @@
+package libs
+
+import (
+	"errors"
+	"net/netip"
+	"slices"
+	"testing"
+
+	"github.com/wasilak/cloudpile/resources"
+)
+
+var queryTestItems = []resources.Item{
+	{
+		ID:           "i-web",
+		ARN:          "arn:aws:ec2:eu-central-1:111111111111:instance/i-web",
+		Name:         "web-1",
+		Type:         "EC2 instance",
+		Account:      "111111111111",
+		AccountAlias: "prod",
+		Region:       "eu-central-1",
+		IP:           "10.1.2.3",
+		VpcID:        "vpc-a",
+		Tags:         []resources.ItemTag{{Key: "Team", Value: "payments"}},
+	},
+	{
+		ID:           "db-main",
+		Name:         "db main",
+		Type:         "RDS instance",
+		Account:      "222222222222",
+		AccountAlias: "staging",
+		Region:       "us-east-1",
+		IP:           "10.2.0.5",
+		VpcID:        "vpc-b",
+		Tags:         []resources.ItemTag{{Key: "Team", Value: "data"}},
+	},
+	{
+		ID:           "vpc-a",
+		Type:         "VPC",
+		Account:      "111111111111",
+		AccountAlias: "prod",
+		Region:       "eu-central-1",
+		VpcID:        "vpc-a",
+		CIDRs:        []string{"10.1.0.0/16"},
+	},
+	{
+		ID:        "eni-v6",
+		Type:      "Network interface (interface)",
+		Region:    "eu-west-1",
+		Addresses: []resources.Address{{IP: "2001:db8::10", Kind: resources.AddressIPv6}},
+	},
+}
+
+func matchedIDs(query Query) []string {
+	ids := []string{}
+	for _, item := range queryTestItems {
+		if query == nil || query.Match(item) {
+			ids = append(ids, item.ID)
+		}
+	}
+
+	return ids
+}
+
+func TestParseQuery(t *testing.T) {
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{"", []string{"i-web", "db-main", "vpc-a", "eni-v6"}},
+		{"web-1", []string{"i-web"}},
+		{"WEB-1", []string{"i-web"}},
+
+		// precedence and implicit AND
+		{"type:ec2 region:eu-*", []string{"i-web"}},
+		{"type:ec2 AND region:us-*", []string{}},
+		{"type:ec2 & region:eu-*", []string{"i-web"}},
+		{"type:ec2 OR type:vpc", []string{"i-web", "vpc-a"}},
+		{"type:ec2 | type:vpc", []string{"i-web", "vpc-a"}},
+		{"type:ec2, type:vpc", []string{"i-web", "vpc-a"}},
+		{"region:eu-central-1 type:ec2 | type:rds", []string{"i-web", "db-main"}},
+		{"region:eu-central-1 (type:ec2 | type:rds)", []string{"i-web"}},
+
+		// negation
+		{"-type:ec2 region:eu-central-1", []string{"vpc-a"}},
+		{"NOT region:eu-*", []string{"db-main"}},
+		{"!tag:Team", []string{"vpc-a", "eni-v6"}},
+		{"not not type:vpc", []string{"vpc-a"}},
+
+		// quoting and wildcards
+		{`name:"db main"`, []string{"db-main"}},
+		{`"db main"`, []string{"db-main"}},
+		{`"AND"`, []string{}},
+		{"name:web-?", []string{"i-web"}},
+		{"name:*", []string{"i-web", "db-main"}},
+		{"tag:Team=pay*", []string{"i-web"}},
+		{"tag:team", []string{"i-web", "db-main"}},
+		{"Team=data", []string{"db-main"}},
+		{"account:staging", []string{"db-main"}},
+		{"account:111111111111", []string{"i-web", "vpc-a"}},
+		{"vpc:vpc-a", []string{"i-web", "vpc-a"}},
+
+		// ARNs are bare terms, unless the value after arn: is not an ARN
+		{"arn:aws:ec2:eu-central-1:111111111111:instance/i-web", []string{"i-web"}},
+		{"arn:*i-web", []string{"i-web"}},
+
+		// addresses, CIDRs and ranges match addresses and overlapping networks
+		{"10.1.2.3", []string{"i-web", "vpc-a"}},
+		{"10.1.0.0/16", []string{"i-web", "vpc-a"}},
+		{"ip:10.2.0.1-10.2.0.9", []string{"db-main"}},
+		{"ip:10.3.0.0/16", []string{}},
+		{"::ffff:10.2.0.5", []string{"db-main"}},
+		{"2001:db8::/64", []string{"eni-v6"}},
+	}
+
+	for _, tt := range tests {
+		query, err := ParseQuery(tt.query)
+		if err != nil {
+			t.Errorf("ParseQuery(%q) error: %v", tt.query, err)
+			continue
+		}
+
+		if got := matchedIDs(query); !slices.Equal(got, tt.want) {
+			t.Errorf("ParseQuery(%q) matches %v, want %v", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestParseQueryErrors(t *testing.T) {
+	tests := []struct {
+		query    string
+		position int
+		token    string
+		message  string
+	}{
+		{"(type:ec2", 0, "(", "unclosed parenthesis"},
+		{"type:ec2 )", 9, ")", "unexpected"},
+		{"AND web", 0, "AND", "unexpected"},
+		{"type:ec2 OR", 11, "", "unexpected end of query"},
+		{`name:"web`, 5, `"web`, "unterminated quote"},
+		{"type:", 0, "type:", "missing value of field"},
+		{"web (", 5, "", "unexpected end of query"},
+	}
+
+	for _, tt := range tests {
+		_, err := ParseQuery(tt.query)
+
+		var queryErr *QueryError
+		if !errors.As(err, &queryErr) {
+			t.Errorf("ParseQuery(%q) error = %v, want QueryError", tt.query, err)
+			continue
+		}
+
+		if queryErr.Position != tt.position || queryErr.Token != tt.token || queryErr.Message != tt.message {
+			t.Errorf("ParseQuery(%q) error = %+v, want %q %q at %d", tt.query, queryErr, tt.message, tt.token, tt.position)
+		}
+	}
+}
+
+func TestParseIPRange(t *testing.T) {
+	tests := []struct {
+		value    string
+		from, to string
+		wantErr  bool
+	}{
+		{value: "10.1.2.3", from: "10.1.2.3", to: "10.1.2.3"},
+		{value: "::ffff:10.1.2.3", from: "10.1.2.3", to: "10.1.2.3"},
+		{value: "10.1.2.3/16", from: "10.1.0.0", to: "10.1.255.255"},
+		{value: "10.1.2.3-10.1.2.40", from: "10.1.2.3", to: "10.1.2.40"},
+		{value: "2001:db8::/120", from: "2001:db8::", to: "2001:db8::ff"},
+		{value: "10.1.2.40-10.1.2.3", wantErr: true},
+		{value: "10.1.2.3-::1", wantErr: true},
+		{value: "10.1.2-10.1.2.3", wantErr: true},
+		{value: "web-1", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		query, err := ParseIPRange(tt.value)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("ParseIPRange(%q) = %v, want error", tt.value, query)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("ParseIPRange(%q) error: %v", tt.value, err)
+			continue
+		}
+
+		got := query.(ipRangeQuery)
+		if got.from.String() != tt.from || got.to.String() != tt.to {
+			t.Errorf("ParseIPRange(%q) = %s-%s, want %s-%s", tt.value, got.from, got.to, tt.from, tt.to)
+		}
+	}
+}
+
+func TestLastAddr(t *testing.T) {
+	tests := []struct {
+		prefix string
+		want   string
+	}{
+		{"10.1.0.0/16", "10.1.255.255"},
+		{"10.1.2.3/8", "10.255.255.255"},
+		{"10.1.2.3/32", "10.1.2.3"},
+		{"0.0.0.0/0", "255.255.255.255"},
+		{"2001:db8::/64", "2001:db8::ffff:ffff:ffff:ffff"},
+	}
+
+	for _, tt := range tests {
+		if got := lastAddr(netip.MustParsePrefix(tt.prefix)); got.String() != tt.want {
+			t.Errorf("lastAddr(%s) = %s, want %s", tt.prefix, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package libs
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/spf13/viper"
+	"github.com/wasilak/cloudpile/resources"
+)
+
+// allRegions in regions of an account stands for all regions enabled in it.
+const allRegions = "all"
+
+// knownRegions lists regions of all partitions, after partitions.json of the
+// SDK. Regions launched later have to be added here before they can be configured.
+var knownRegions = []string{
+	// aws
+	"af-south-1", "ap-east-1", "ap-east-2", "ap-northeast-1", "ap-northeast-2", "ap-northeast-3",
+	"ap-south-1", "ap-south-2", "ap-southeast-1", "ap-southeast-2", "ap-southeast-3", "ap-southeast-4",
+	"ap-southeast-5", "ap-southeast-6", "ap-southeast-7", "ca-central-1", "ca-west-1",
+	"eu-central-1", "eu-central-2", "eu-north-1", "eu-south-1", "eu-south-2", "eu-west-1", "eu-west-2",
+	"eu-west-3", "il-central-1", "me-central-1", "me-south-1", "mx-central-1", "sa-east-1",
+	"us-east-1", "us-east-2", "us-west-1", "us-west-2",
+	// aws-cn
+	"cn-north-1", "cn-northwest-1",
+	// aws-eusc
+	"eusc-de-east-1",
+	// aws-iso, aws-iso-b, aws-iso-e, aws-iso-f
+	"us-iso-east-1", "us-iso-west-1", "us-isob-east-1", "us-isob-west-1", "eu-isoe-west-1",
+	"us-isof-east-1", "us-isof-south-1",
+	// aws-us-gov
+	"us-gov-east-1", "us-gov-west-1",
+}
+
+var (
+	regionsMu sync.Mutex
+	// enabledRegions holds regions enabled in accounts, by account config key.
+	enabledRegions = map[string][]string{}
+)
+
+// accountRegions returns regions of account without excluded ones, "all" is
+// expanded to regions enabled in the account.
+func accountRegions(ctx context.Context, awsConfig AWSConfig) ([]string, error) {
+	regions := awsConfig.Regions
+
+	if slices.Contains(regions, allRegions) {
+		var err error
+		if regions, err = discoverRegions(ctx, awsConfig); err != nil {
+			return nil, err
+		}
+	}
+
+	return slices.DeleteFunc(slices.Clone(regions), func(region string) bool {
+		return slices.Contains(awsConfig.ExcludeRegions, region)
+	}), nil
+}
+
+// discoverRegions returns regions enabled in account, listing them on first use.
+func discoverRegions(ctx context.Context, awsConfig AWSConfig) ([]string, error) {
+	regionsMu.Lock()
+	regions, ok := enabledRegions[awsConfig.key()]
+	regionsMu.Unlock()
+
+	if ok {
+		return regions, nil
+	}
+
+	return listRegions(ctx, awsConfig)
+}
+
+// listRegions lists regions enabled in account, that is regions not requiring
+// opt-in and regions the account opted in to, and keeps them for discoverRegions.
+func listRegions(ctx context.Context, awsConfig AWSConfig) ([]string, error) {
+	cfg, err := awsConfigFor(ctx, awsConfig, apiRegion(awsConfig.Regions))
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := ec2.NewFromConfig(cfg).DescribeRegions(ctx, &ec2.DescribeRegionsInput{})
+	if err != nil {
+		return nil, fmt.Errorf("region discovery failed: %w", err)
+	}
+
+	regions := []string{}
+	for _, r := range result.Regions {
+		regions = append(regions, aws.ToString(r.RegionName))
+	}
+	slices.Sort(regions)
+
+	regionsMu.Lock()
+	enabledRegions[awsConfig.key()] = regions
+	regionsMu.Unlock()
+
+	return regions, nil
+}
+
+// apiRegion picks region for account wide API calls, any region works for
+// them, one listed next to "all" is preferred.
+func apiRegion(regions []string) string {
+	for _, region := range regions {
+		if region != allRegions {
+			return region
+		}
+	}
+
+	return "us-east-1"
+}
+
+// rediscoverRegions lists regions of accounts with "all" regions again,
+// previous regions are kept when listing fails.
+func rediscoverRegions(ctx context.Context) {
+	for _, awsConfig := range Accounts(ctx) {
+		if !slices.Contains(awsConfig.Regions, allRegions) {
+			continue
+		}
+
+		if _, err := listRegions(ctx, awsConfig); err != nil {
+			slog.Error("Region discovery failed", "account", awsConfig.Name(), "error", err)
+		}
+	}
+}
+
+// runsIn reports whether collector runs in region, global collectors only run
+// in resources.GlobalRegion. Regions of regional collectors can be narrowed
+// down with collectors.<name>.regions.
+func runsIn(collector resources.Collector, region string) bool {
+	if collector.Global {
+		return region == resources.GlobalRegion
+	}
+
+	if region == resources.GlobalRegion || !collector.SupportsRegion(region) {
+		return false
+	}
+
+	regions := viper.GetStringSlice(fmt.Sprintf("collectors.%s.regions", collector.Name))
+
+	return len(regions) == 0 || slices.Contains(regions, region)
+}
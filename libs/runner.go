@@ -1,6 +1,7 @@
 package libs
 
 import (
+	"context"
 	"time"
 
 	"log/slog"
@@ -8,19 +9,94 @@ import (
 	"github.com/wasilak/cloudpile/cache"
 )
 
-func Runner() {
+// Runner refreshes cache right away and then checks for expired entries on
+// every tick of the shortest cache TTL until ctx is done.
+func Runner(ctx context.Context) {
 
-	ticker := time.NewTicker(cache.CacheInstance.TTL)
+	ticker := time.NewTicker(cache.CacheInstance.MinTTL())
 
-	slog.Debug("Initial cache refresh...")
+	// persistent cache already has data to serve, so don't hold startup back
+	if !cache.CacheInstance.Persistent {
+		slog.Debug("Initial cache refresh...")
 
-	Run([]string{}, cache.CacheInstance, true)
+		refresh(ctx)
 
-	slog.Debug("Cache refresh done", "next_in", cache.CacheInstance.TTL)
+		slog.Debug("Cache refresh done", "next_in", cache.CacheInstance.MinTTL())
+	}
 
 	go func() {
-		for range ticker.C {
-			Run([]string{}, cache.CacheInstance, true)
+		defer ticker.Stop()
+
+		if cache.CacheInstance.Persistent {
+			refresh(ctx)
+		}
+
+		for {
+			select {
+			case <-ctx.Done():
+				slog.Debug("Cache refresh stopped")
+				unlock()
+				return
+			case <-ticker.C:
+				refresh(ctx)
+			}
 		}
 	}()
 }
+
+// refresh rediscovers organization accounts, collects missing and expired
+// entries into cache and persists it. With a shared cache backend only the
+// replica holding the lock refreshes, the others load accounts it discovered.
+func refresh(ctx context.Context) {
+	store, shared := cache.CacheInstance.Cache.(cache.Store)
+
+	if locker, ok := cache.CacheInstance.Cache.(cache.Locker); ok {
+		// lock outlives a tick, so the leader keeps it as long as it's alive
+		leader, err := locker.TryLock(ctx, 2*cache.CacheInstance.MinTTL())
+		if err != nil {
+			slog.Error("Cache lock failed", "error", err)
+			return
+		}
+
+		if !leader {
+			// every replica needs the current account list to serve requests
+			if shared {
+				if err := loadDiscovery(ctx, store); err != nil {
+					slog.Error("Loading shared discovery failed", "error", err)
+				}
+			}
+
+			slog.Debug("Cache refresh skipped, another replica holds the lock")
+			return
+		}
+	}
+
+	DiscoverAccounts(ctx)
+
+	if shared {
+		if err := shareDiscovery(ctx, store); err != nil {
+			slog.Error("Sharing discovery failed", "error", err)
+		}
+	}
+
+	Run(ctx, nil, cache.CacheInstance, RefreshExpired)
+
+	if err := cache.CacheInstance.Cache.Flush(); err != nil {
+		slog.Error("Cache flush failed", "error", err)
+	}
+}
+
+// unlock lets another replica take over refreshes right away on shutdown.
+func unlock() {
+	locker, ok := cache.CacheInstance.Cache.(cache.Locker)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := locker.Unlock(ctx); err != nil {
+		slog.Error("Cache unlock failed", "error", err)
+	}
+}
@@ -0,0 +1,82 @@
+package libs
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+)
+
+// session holds AWS config and account ID of an account. Both are resolved
+// once and reused by all refreshes and requests, credentials caches of the
+// config renew credentials when they expire.
+type session struct {
+	mu        sync.Mutex
+	cfg       *aws.Config
+	accountID string
+}
+
+var (
+	sessionsMu sync.Mutex
+	sessions   = map[string]*session{}
+)
+
+// key identifies credentials of account config.
+func (c AWSConfig) key() string {
+	return strings.Join([]string{c.Type, c.Profile, c.HubRoleARN, c.managementRoleARN, c.IAMRoleARN, c.ExternalID, c.WebIdentityRoleARN, c.AccessKeyID, c.AccountAlias}, "|")
+}
+
+func getSession(awsConfig AWSConfig) *session {
+	sessionsMu.Lock()
+	defer sessionsMu.Unlock()
+
+	s, ok := sessions[awsConfig.key()]
+	if !ok {
+		s = &session{}
+		sessions[awsConfig.key()] = s
+	}
+
+	return s
+}
+
+// awsConfigFor returns config of account in region. Config is built on first
+// use, failures are not kept so that the next call retries.
+func awsConfigFor(ctx context.Context, awsConfig AWSConfig, region string) (aws.Config, error) {
+	s := getSession(awsConfig)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.cfg == nil {
+		cfg, err := newAWSV2Config(ctx, awsConfig, region)
+		if err != nil {
+			return cfg, err
+		}
+		s.cfg = &cfg
+	}
+
+	// copies share the credentials cache, so roles are assumed once per account
+	cfg := s.cfg.Copy()
+	cfg.Region = region
+
+	return cfg, nil
+}
+
+// accountIDFor returns ID of account, calling STS with cfg only the first time.
+func accountIDFor(ctx context.Context, awsConfig AWSConfig, cfg aws.Config) (string, error) {
+	s := getSession(awsConfig)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.accountID == "" {
+		accountID, err := getAccountId(ctx, cfg)
+		if err != nil {
+			return "", err
+		}
+		s.accountID = accountID
+	}
+
+	return s.accountID, nil
+}
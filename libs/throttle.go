@@ -0,0 +1,118 @@
+package libs
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsmiddleware "github.com/aws/aws-sdk-go-v2/aws/middleware"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+	"github.com/aws/smithy-go/middleware"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/spf13/viper"
+	"golang.org/x/time/rate"
+)
+
+var (
+	awsRetries = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "cloudpile_aws_retries_total",
+		Help: "Number of retried AWS API calls.",
+	}, []string{"account"})
+
+	awsThrottles = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "cloudpile_aws_throttles_total",
+		Help: "Number of AWS API calls rejected with a throttling error.",
+	}, []string{"account"})
+
+	workersOnce sync.Once
+	workers     chan struct{}
+
+	limitersMu sync.Mutex
+	limiters   = map[string]*rate.Limiter{}
+)
+
+// acquireWorker blocks until one of collectors.max_concurrency slots is free.
+// Returned func releases the slot.
+func acquireWorker(ctx context.Context) (func(), error) {
+	workersOnce.Do(func() {
+		workers = make(chan struct{}, max(viper.GetInt("collectors.max_concurrency"), 1))
+	})
+
+	select {
+	case workers <- struct{}{}:
+		return func() { <-workers }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// getLimiter returns shared rate limiter for account and AWS service.
+// Zero collectors.rate_limit means unlimited.
+func getLimiter(account, service string) *rate.Limiter {
+	limitersMu.Lock()
+	defer limitersMu.Unlock()
+
+	key := account + "/" + service
+
+	limiter, ok := limiters[key]
+	if !ok {
+		limit := rate.Limit(viper.GetFloat64("collectors.rate_limit"))
+		if limit <= 0 {
+			limit = rate.Inf
+		}
+
+		limiter = rate.NewLimiter(limit, max(viper.GetInt("collectors.rate_burst"), 1))
+		limiters[key] = limiter
+	}
+
+	return limiter
+}
+
+// withRateLimit adds middleware waiting for account limiter before every attempt of an API call.
+func withRateLimit(account string) func(*middleware.Stack) error {
+	return func(stack *middleware.Stack) error {
+		return stack.Finalize.Add(middleware.FinalizeMiddlewareFunc("CloudpileRateLimit",
+			func(ctx context.Context, in middleware.FinalizeInput, next middleware.FinalizeHandler) (middleware.FinalizeOutput, middleware.Metadata, error) {
+				if err := getLimiter(account, awsmiddleware.GetServiceID(ctx)).Wait(ctx); err != nil {
+					return middleware.FinalizeOutput{}, middleware.Metadata{}, err
+				}
+
+				return next.HandleFinalize(ctx, in)
+			}), middleware.After)
+	}
+}
+
+// countingRetryer is adaptive retryer reporting retries and throttles as metrics.
+type countingRetryer struct {
+	aws.RetryerV2
+	account string
+}
+
+func (r *countingRetryer) RetryDelay(attempt int, err error) (time.Duration, error) {
+	awsRetries.WithLabelValues(r.account).Inc()
+
+	if retry.IsErrorThrottles(retry.DefaultThrottles).IsErrorThrottle(err) == aws.TrueTernary {
+		awsThrottles.WithLabelValues(r.account).Inc()
+	}
+
+	return r.RetryerV2.RetryDelay(attempt, err)
+}
+
+// newRetryer returns retryer factory backing off on throttling errors, up to
+// collectors.retry_max_attempts attempts per API call.
+func newRetryer(account string) func() aws.Retryer {
+	return func() aws.Retryer {
+		return &countingRetryer{
+			account: account,
+			RetryerV2: retry.NewAdaptiveMode(func(o *retry.AdaptiveModeOptions) {
+				o.StandardOptions = append(o.StandardOptions, func(so *retry.StandardOptions) {
+					if maxAttempts := viper.GetInt("collectors.retry_max_attempts"); maxAttempts > 0 {
+						so.MaxAttempts = maxAttempts
+					}
+				})
+			}),
+		}
+	}
+}
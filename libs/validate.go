@@ -0,0 +1,413 @@
+package libs
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-viper/mapstructure/v2"
+	"github.com/wasilak/cloudpile/cache"
+	"github.com/wasilak/cloudpile/resources"
+	"go.yaml.in/yaml/v3"
+)
+
+// ConfigProblem is a single violation of the config schema.
+type ConfigProblem struct {
+	Line    int
+	Path    string
+	Message string
+}
+
+func (p ConfigProblem) String() string {
+	return fmt.Sprintf("line %d: %s: %s", p.Line, p.Path, p.Message)
+}
+
+// configValidator collects problems found while walking the config document.
+type configValidator struct {
+	problems []ConfigProblem
+}
+
+func (v *configValidator) add(node *yaml.Node, path, format string, args ...any) {
+	v.problems = append(v.problems, ConfigProblem{Line: node.Line, Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+// ValidateConfig checks config file at path against the schema: known keys,
+// required fields of every account type, region names, resource types and
+// durations. Unreadable or malformed files are returned as error.
+func ValidateConfig(path string) ([]ConfigProblem, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var document yaml.Node
+	if err := yaml.Unmarshal(data, &document); err != nil {
+		return nil, err
+	}
+
+	v := &configValidator{}
+
+	if len(document.Content) == 0 {
+		return v.problems, nil
+	}
+
+	root := document.Content[0]
+	if !v.mapping(root, "config") {
+		return v.problems, nil
+	}
+
+	v.keys(root, "", []string{"listen", "loglevel", "logformat", "debug", "cache", "collectors", "aws"})
+
+	if node := value(root, "cache"); node != nil {
+		v.cache(node)
+	}
+
+	if node := value(root, "collectors"); node != nil {
+		v.collectors(node)
+	}
+
+	if node := value(root, "aws"); node == nil {
+		v.add(root, "aws", "at least one account is required")
+	} else {
+		v.accounts(node)
+	}
+
+	slices.SortStableFunc(v.problems, func(a, b ConfigProblem) int {
+		return a.Line - b.Line
+	})
+
+	return v.problems, nil
+}
+
+func (v *configValidator) cache(node *yaml.Node) {
+	if !v.mapping(node, "cache") {
+		return
+	}
+
+	v.keys(node, "cache", append(structKeys(cache.Config{}), "enabled", "ttl"))
+
+	v.duration(value(node, "ttl"), "cache.TTL", false)
+	v.duration(value(node, "max_stale"), "cache.max_stale", true)
+
+	if backend := value(node, "backend"); backend != nil && !slices.Contains([]string{"", "memory", "file", "redis"}, backend.Value) {
+		v.add(backend, "cache.backend", "unknown backend %q, valid backends are: memory, file, redis", backend.Value)
+	}
+
+	if redis := value(node, "redis"); redis != nil && v.mapping(redis, "cache.redis") {
+		v.keys(redis, "cache.redis", structKeys(cache.RedisConfig{}))
+	}
+
+	if backend := value(node, "backend"); backend != nil && backend.Value == "redis" {
+		var address *yaml.Node
+		if redis := value(node, "redis"); redis != nil {
+			address = value(redis, "address")
+		}
+
+		if address == nil || address.Value == "" {
+			v.add(backend, "cache.redis.address", "address is required for redis backend")
+		}
+	}
+
+	if typeTTL := value(node, "type_ttl"); typeTTL != nil && v.mapping(typeTTL, "cache.type_ttl") {
+		for i := 0; i < len(typeTTL.Content); i += 2 {
+			key, val := typeTTL.Content[i], typeTTL.Content[i+1]
+			path := "cache.type_ttl." + key.Value
+
+			v.resource(key, path, strings.ToLower(key.Value))
+			v.duration(val, path, false)
+		}
+	}
+}
+
+// collectorSettings are collectors keys which are not resource types.
+var collectorSettings = []string{"timeout", "max_concurrency", "rate_limit", "rate_burst", "retry_max_attempts", "tagging_api"}
+
+func (v *configValidator) collectors(node *yaml.Node) {
+	if !v.mapping(node, "collectors") {
+		return
+	}
+
+	for i := 0; i < len(node.Content); i += 2 {
+		key, val := node.Content[i], node.Content[i+1]
+		path := "collectors." + key.Value
+
+		// viper keys are case insensitive
+		switch name := strings.ToLower(key.Value); name {
+		case "timeout":
+			v.duration(val, path, true)
+		case "rate_limit":
+			if _, err := strconv.ParseFloat(val.Value, 64); err != nil {
+				v.add(val, path, "%q is not a number", val.Value)
+			}
+		case "max_concurrency", "rate_burst", "retry_max_attempts":
+			v.integer(val, path)
+		case "tagging_api":
+			if _, err := strconv.ParseBool(val.Value); err != nil {
+				v.add(val, path, "%q is not a boolean", val.Value)
+			}
+		default:
+			if _, ok := resources.GetCollector(name); !ok {
+				v.unknownKey(key, path, append(slices.Clone(collectorSettings), resources.CollectorNames()...))
+				continue
+			}
+
+			if !v.mapping(val, path) {
+				continue
+			}
+
+			v.keys(val, path, []string{"page_size", "max_items", "timeout", "regions"})
+			v.integer(value(val, "page_size"), path+".page_size")
+			v.integer(value(val, "max_items"), path+".max_items")
+			v.duration(value(val, "timeout"), path+".timeout", true)
+			v.regions(value(val, "regions"), path+".regions", false)
+		}
+	}
+}
+
+func (v *configValidator) accounts(node *yaml.Node) {
+	if node.Kind != yaml.SequenceNode {
+		v.add(node, "aws", "must be a list of accounts")
+		return
+	}
+
+	for i, entry := range node.Content {
+		path := fmt.Sprintf("aws[%d]", i)
+
+		if !v.mapping(entry, path) {
+			continue
+		}
+
+		found := len(v.problems)
+
+		v.keys(entry, path, structKeys(AWSConfig{}))
+
+		if value(entry, "type") == nil {
+			v.add(entry, path, "type is required")
+		}
+
+		// account wide calls, like organization and region discovery, need a region
+		if regions := value(entry, "regions"); regions == nil || (regions.Kind == yaml.SequenceNode && len(regions.Content) == 0) {
+			v.add(entry, path, "regions is required, list regions or \"all\"")
+		}
+
+		v.regions(value(entry, "regions"), path+".regions", true)
+		v.regions(value(entry, "exclude_regions"), path+".exclude_regions", false)
+		v.duration(value(entry, "session_duration"), path+".session_duration", true)
+
+		if list := value(entry, "resources"); list != nil && v.sequence(list, path+".resources") {
+			for _, item := range list.Content {
+				v.resource(item, path+".resources", item.Value)
+			}
+		}
+
+		for _, key := range []string{"include", "exclude"} {
+			if selector := value(entry, key); selector != nil && v.mapping(selector, path+"."+key) {
+				v.keys(selector, path+"."+key, structKeys(AccountSelector{}))
+			}
+		}
+
+		// required fields are only checked in structurally valid entries
+		if len(v.problems) > found {
+			continue
+		}
+
+		awsConfig, err := decodeAccount(entry)
+		if err != nil {
+			v.add(entry, path, "%s", err)
+			continue
+		}
+
+		if err := validateCredentials(awsConfig); err != nil {
+			v.add(entry, path, "%s", err)
+		}
+	}
+}
+
+// decodeAccount decodes account entry the way viper does.
+func decodeAccount(entry *yaml.Node) (AWSConfig, error) {
+	var raw map[string]any
+	if err := entry.Decode(&raw); err != nil {
+		return AWSConfig{}, err
+	}
+
+	var awsConfig AWSConfig
+	decoder, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+		DecodeHook:       mapstructure.StringToTimeDurationHookFunc(),
+		WeaklyTypedInput: true,
+		Result:           &awsConfig,
+	})
+	if err != nil {
+		return awsConfig, err
+	}
+
+	return awsConfig, decoder.Decode(raw)
+}
+
+func (v *configValidator) mapping(node *yaml.Node, path string) bool {
+	if node.Kind != yaml.MappingNode {
+		v.add(node, path, "must be a mapping")
+		return false
+	}
+
+	return true
+}
+
+func (v *configValidator) sequence(node *yaml.Node, path string) bool {
+	if node.Kind != yaml.SequenceNode {
+		v.add(node, path, "must be a list")
+		return false
+	}
+
+	return true
+}
+
+// keys reports keys of mapping node which are not known, case insensitively as viper reads them.
+func (v *configValidator) keys(node *yaml.Node, path string, known []string) {
+	for i := 0; i < len(node.Content); i += 2 {
+		key := node.Content[i]
+		if !slices.Contains(known, strings.ToLower(key.Value)) {
+			v.unknownKey(key, strings.TrimPrefix(path+"."+key.Value, "."), known)
+		}
+	}
+}
+
+func (v *configValidator) unknownKey(key *yaml.Node, path string, known []string) {
+	if suggestion := closest(strings.ToLower(key.Value), known); suggestion != "" {
+		v.add(key, path, "unknown key %q, did you mean %q?", key.Value, suggestion)
+		return
+	}
+
+	v.add(key, path, "unknown key %q", key.Value)
+}
+
+// duration checks a duration, it must be positive or, with allowZero, not negative.
+func (v *configValidator) duration(node *yaml.Node, path string, allowZero bool) {
+	if node == nil {
+		return
+	}
+
+	d, err := time.ParseDuration(node.Value)
+	switch {
+	case err != nil:
+		v.add(node, path, "invalid duration %q, e.g. 90s, 5m or 1h30m", node.Value)
+	case d < 0 || (d == 0 && !allowZero):
+		if allowZero {
+			v.add(node, path, "duration %q must not be negative", node.Value)
+			return
+		}
+		v.add(node, path, "duration %q must be positive", node.Value)
+	}
+}
+
+func (v *configValidator) integer(node *yaml.Node, path string) {
+	if node == nil {
+		return
+	}
+
+	if _, err := strconv.Atoi(node.Value); err != nil {
+		v.add(node, path, "%q is not an integer", node.Value)
+	}
+}
+
+func (v *configValidator) resource(node *yaml.Node, path, name string) {
+	if _, ok := resources.GetCollector(name); !ok {
+		if suggestion := closest(name, resources.CollectorNames()); suggestion != "" {
+			v.add(node, path, "unknown resource %q, did you mean %q?", name, suggestion)
+			return
+		}
+		v.add(node, path, "unknown resource %q, valid resources are: %s", name, strings.Join(resources.CollectorNames(), ", "))
+	}
+}
+
+// regions checks list of region names, allowAll permits "all".
+func (v *configValidator) regions(node *yaml.Node, path string, allowAll bool) {
+	if node == nil || !v.sequence(node, path) {
+		return
+	}
+
+	for _, region := range node.Content {
+		if allowAll && region.Value == allRegions {
+			continue
+		}
+
+		if slices.Contains(knownRegions, region.Value) {
+			continue
+		}
+
+		if suggestion := closest(region.Value, knownRegions); suggestion != "" {
+			v.add(region, path, "unknown region %q, did you mean %q?", region.Value, suggestion)
+			continue
+		}
+		v.add(region, path, "unknown region %q", region.Value)
+	}
+}
+
+// value returns value node of key in mapping node, case insensitively.
+func value(node *yaml.Node, key string) *yaml.Node {
+	if node.Kind != yaml.MappingNode {
+		return nil
+	}
+
+	for i := 0; i < len(node.Content); i += 2 {
+		if strings.EqualFold(node.Content[i].Value, key) {
+			return node.Content[i+1]
+		}
+	}
+
+	return nil
+}
+
+// structKeys returns mapstructure keys of s fields.
+func structKeys(s any) []string {
+	keys := []string{}
+
+	t := reflect.TypeOf(s)
+	for i := 0; i < t.NumField(); i++ {
+		if key, _, _ := strings.Cut(t.Field(i).Tag.Get("mapstructure"), ","); key != "" {
+			keys = append(keys, key)
+		}
+	}
+
+	return keys
+}
+
+// closest returns the known value within two edits of value, if any.
+func closest(value string, known []string) string {
+	best, bestDistance := "", 3
+
+	for _, candidate := range known {
+		if distance := editDistance(value, candidate); distance < bestDistance {
+			best, bestDistance = candidate, distance
+		}
+	}
+
+	return best
+}
+
+// editDistance returns the Levenshtein distance of a and b.
+func editDistance(a, b string) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+
+	return previous[len(b)]
+}
@@ -0,0 +1,182 @@
+package libs
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestValidateConfig(t *testing.T) {
+	t.Setenv("AWS_ROLE_ARN", "")
+	t.Setenv("AWS_WEB_IDENTITY_TOKEN_FILE", "")
+
+	tests := []struct {
+		name   string
+		config string
+		want   []string
+	}{
+		{
+			name: "valid",
+			config: `
+listen: 0.0.0.0:3000
+cache:
+  enabled: true
+  TTL: 5m
+  max_stale: 0s
+  backend: redis
+  redis:
+    address: redis:6379
+  type_ttl:
+    SG: 30s
+collectors:
+  timeout: 0s
+  EC2:
+    page_size: 100
+    regions: [eu-central-1]
+aws:
+  - type: profile
+    profile: default
+    regions: [all]
+    exclude_regions: [ap-east-1]
+    resources: [ec2, sg]
+  - type: organization
+    role_template: arn:aws:iam::{account_id}:role/reader
+    regions: [eu-central-1]
+    resources: [s3]
+`,
+			want: []string{},
+		},
+		{
+			name: "unknown keys with suggestions",
+			config: `
+cache:
+  enabeld: true
+aws:
+  - type: iam
+    iam_role_am: arn:aws:iam::123456789012:role/reader
+    regions: [eu-central-1]
+`,
+			want: []string{
+				`line 3: cache.enabeld: unknown key "enabeld", did you mean "enabled"?`,
+				`line 6: aws[0].iam_role_am: unknown key "iam_role_am", did you mean "iam_role_arn"?`,
+			},
+		},
+		{
+			name: "required fields per type",
+			config: `
+aws:
+  - profile: default
+    regions: [eu-central-1]
+  - type: iam
+    regions: [eu-central-1]
+  - type: static
+    access_key_id: AKIA
+    regions: [eu-central-1]
+  - type: web_identity
+    regions: [eu-central-1]
+  - type: organization
+    role_template: arn:aws:iam::123456789012:role/reader
+    regions: [eu-central-1]
+  - type: magic
+    regions: [eu-central-1]
+  - type: profile
+`,
+			want: []string{
+				`line 3: aws[0]: type is required`,
+				`line 5: aws[1]: type iam requires iam_role_arn`,
+				`line 7: aws[2]: type static requires access_key_id and secret_access_key`,
+				`line 10: aws[3]: type web_identity requires web_identity_role_arn or AWS_ROLE_ARN`,
+				`line 12: aws[4]: role_template "arn:aws:iam::123456789012:role/reader" has no {account_id} placeholder`,
+				`line 15: aws[5]: unknown type "magic", valid types are: default, profile, iam, sso, web_identity, static, organization`,
+				`line 17: aws[6]: regions is required, list regions or "all"`,
+			},
+		},
+		{
+			name: "regions and resources",
+			config: `
+collectors:
+  lambda:
+    regions: [all]
+aws:
+  - type: default
+    regions: [eu-central-1, eu-west-4, mars-1]
+    exclude_regions: [all]
+    resources: [ec2, ec3]
+`,
+			want: []string{
+				`line 4: collectors.lambda.regions: unknown region "all"`,
+				`line 7: aws[0].regions: unknown region "eu-west-4", did you mean "eu-west-1"?`,
+				`line 7: aws[0].regions: unknown region "mars-1"`,
+				`line 8: aws[0].exclude_regions: unknown region "all"`,
+				`line 9: aws[0].resources: unknown resource "ec3", did you mean "ec2"?`,
+			},
+		},
+		{
+			name: "durations and backend",
+			config: `
+cache:
+  TTL: 0s
+  max_stale: -1h
+  backend: redis
+  type_ttl:
+    sg: -1m
+    ec2: soon
+collectors:
+  timeout: -5s
+aws:
+  - type: default
+    session_duration: 1h
+    regions: [eu-central-1]
+`,
+			want: []string{
+				`line 3: cache.TTL: duration "0s" must be positive`,
+				`line 4: cache.max_stale: duration "-1h" must not be negative`,
+				`line 5: cache.redis.address: address is required for redis backend`,
+				`line 7: cache.type_ttl.sg: duration "-1m" must be positive`,
+				`line 8: cache.type_ttl.ec2: invalid duration "soon", e.g. 90s, 5m or 1h30m`,
+				`line 10: collectors.timeout: duration "-5s" must not be negative`,
+			},
+		},
+		{
+			name: "missing accounts",
+			config: `
+listen: 0.0.0.0:3000
+`,
+			want: []string{`line 2: aws: at least one account is required`},
+		},
+	}
+
+	for _, tt := range tests {
+		path := filepath.Join(t.TempDir(), "cloudpile.yml")
+		if err := os.WriteFile(path, []byte(tt.config), 0o600); err != nil {
+			t.Fatal(err)
+		}
+
+		problems, err := ValidateConfig(path)
+		if err != nil {
+			t.Errorf("%s: ValidateConfig error: %v", tt.name, err)
+			continue
+		}
+
+		got := []string{}
+		for _, problem := range problems {
+			got = append(got, problem.String())
+		}
+
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("%s: ValidateConfig problems:\n%q\nwant:\n%q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestValidateConfigMalformed(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cloudpile.yml")
+	if err := os.WriteFile(path, []byte("aws: [\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := ValidateConfig(path); err == nil {
+		t.Error("ValidateConfig of malformed YAML returned no error")
+	}
+}
@@ -1,13 +1,35 @@
 package resources
 
+import (
+	"context"
+	"strings"
+	"time"
+)
+
 type ItemTag struct {
 	Key   string `json:"key"`
 	Value string `json:"value"`
 }
 
+// Address kinds
+const (
+	AddressPrivate   = "private"
+	AddressSecondary = "secondary"
+	AddressPublic    = "public"
+	AddressElastic   = "elastic"
+	AddressIPv6      = "ipv6"
+)
+
+// Address is a single IP address of an item.
+type Address struct {
+	IP   string `json:"ip"`
+	Kind string `json:"kind"`
+}
+
 type Item struct {
 	ID             string    `json:"id"`
 	ARN            string    `json:"arn"`
+	Name           string    `json:"name"`
 	Type           string    `json:"type"`
 	Tags           []ItemTag `json:"tags"`
 	Account        string    `json:"account"`
@@ -15,10 +37,97 @@ type Item struct {
 	Region         string    `json:"region"`
 	IP             string    `json:"ip"`
 	PrivateDNSName string    `json:"private_dns_name"`
+	VpcID          string    `json:"vpcId,omitempty"`
+	SubnetID       string    `json:"subnetId,omitempty"`
+	State          string    `json:"state,omitempty"`
+	CreatedAt      time.Time `json:"createdAt,omitzero"`
+	// Details holds type specific facts, e.g. what a network interface is attached to.
+	Details map[string]string `json:"details,omitempty"`
+	// Addresses holds all addresses of item, IP is kept for compatibility
+	// and holds the primary private one.
+	Addresses []Address `json:"addresses,omitempty"`
+	// CIDRs holds address ranges of networks, e.g. VPCs and subnets.
+	CIDRs []string `json:"cidrs,omitempty"`
+	// Targets holds DNS names and addresses a DNS record points to.
+	Targets []string `json:"targets,omitempty"`
+}
+
+// TagValue returns value of tag key, or empty string when item has no such tag.
+func (i *Item) TagValue(key string) string {
+	for _, tag := range i.Tags {
+		if tag.Key == key {
+			return tag.Value
+		}
+	}
+
+	return ""
+}
+
+// SetSubnets sets SubnetID of items in a single subnet, items spanning more
+// subnets list them in details instead.
+func (i *Item) SetSubnets(subnets []string) {
+	switch len(subnets) {
+	case 0:
+	case 1:
+		i.SubnetID = subnets[0]
+	default:
+		if i.Details == nil {
+			i.Details = map[string]string{}
+		}
+		i.Details["subnets"] = strings.Join(subnets, ",")
+	}
+}
+
+// AddAddress adds ip of kind to item addresses, empty and known IPs are skipped.
+func (i *Item) AddAddress(ip string, kind string) {
+	if ip == "" {
+		return
+	}
+
+	for _, address := range i.Addresses {
+		if address.IP == ip {
+			return
+		}
+	}
+
+	i.Addresses = append(i.Addresses, Address{IP: ip, Kind: kind})
+}
+
+// Source is the outcome of a single collector run for one account, region and type.
+type Source struct {
+	Account      string        `json:"account"`
+	AccountAlias string        `json:"accountAlias"`
+	Region       string        `json:"region"`
+	Type         string        `json:"type"`
+	Duration     time.Duration `json:"duration"`
+	ItemCount    int           `json:"itemCount"`
+	Error        string        `json:"error,omitempty"`
+}
+
+// Partition returns the ARN partition of region, e.g. aws-cn for cn-north-1.
+func Partition(region string) string {
+	switch {
+	case strings.HasPrefix(region, "cn-"):
+		return "aws-cn"
+	case strings.HasPrefix(region, "us-gov-"):
+		return "aws-us-gov"
+	case strings.HasPrefix(region, "us-isob-"):
+		return "aws-iso-b"
+	case strings.HasPrefix(region, "us-isof-"):
+		return "aws-iso-f"
+	case strings.HasPrefix(region, "us-iso-"):
+		return "aws-iso"
+	case strings.HasPrefix(region, "eu-isoe-"):
+		return "aws-iso-e"
+	case strings.HasPrefix(region, "eusc-"):
+		return "aws-eusc"
+	}
+
+	return "aws"
 }
 
 type AWSResourceType interface {
-	Get() ([]Item, error)
+	Get(ctx context.Context) ([]Item, error)
 	GetCacheKey() string
 }
 
@@ -28,4 +137,35 @@ type BaseAWSResource struct {
 	AccountAlias string
 	Region       string
 	Type         string
+	// MaxPageSize is the page size requested from the API, 0 uses the API default.
+	MaxPageSize int32
+	// MaxItems caps the number of collected items, 0 means no limit.
+	MaxItems int
+	// SkipTags is set when tags are fetched by a TagSweep instead.
+	SkipTags bool
+	// Buckets is the bucket listing shared by S3 collectors of the account.
+	Buckets *BucketListing
+}
+
+// PageSize returns the configured page size, nil leaves the API default.
+func (r *BaseAWSResource) PageSize() *int32 {
+	if r.MaxPageSize <= 0 {
+		return nil
+	}
+
+	return &r.MaxPageSize
+}
+
+// LimitReached reports whether count hits the configured upper bound of items.
+func (r *BaseAWSResource) LimitReached(count int) bool {
+	return r.MaxItems > 0 && count >= r.MaxItems
+}
+
+// Limit trims items to the configured upper bound.
+func (r *BaseAWSResource) Limit(items []Item) []Item {
+	if r.LimitReached(len(items)) {
+		return items[:r.MaxItems]
+	}
+
+	return items
 }
@@ -0,0 +1,23 @@
+package resources
+
+import "testing"
+
+func TestPartition(t *testing.T) {
+	tests := map[string]string{
+		"eu-central-1":    "aws",
+		"us-east-1":       "aws",
+		"cn-north-1":      "aws-cn",
+		"us-gov-west-1":   "aws-us-gov",
+		"us-iso-east-1":   "aws-iso",
+		"us-isob-east-1":  "aws-iso-b",
+		"us-isof-south-1": "aws-iso-f",
+		"eu-isoe-west-1":  "aws-iso-e",
+		"eusc-de-east-1":  "aws-eusc",
+	}
+
+	for region, want := range tests {
+		if got := Partition(region); got != want {
+			t.Errorf("Partition(%q) = %q, want %q", region, got, want)
+		}
+	}
+}
@@ -6,10 +6,24 @@ import (
 	"log/slog"
 	"regexp"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/autoscaling"
 	"github.com/wasilak/cloudpile/resources"
 )
 
+func init() {
+	resources.Register(resources.Collector{
+		Name:        "asg",
+		DisplayType: "AutoScaling group",
+		New: func(cfg aws.Config, base resources.BaseAWSResource) resources.AWSResourceType {
+			return &ASG{
+				Client:          autoscaling.NewFromConfig(cfg),
+				BaseAWSResource: base,
+			}
+		},
+	})
+}
+
 type ASG struct {
 	Client *autoscaling.Client
 	resources.BaseAWSResource
@@ -19,43 +33,51 @@ func (r *ASG) GetCacheKey() string {
 	return fmt.Sprintf("%s-%s-%s", r.AccountID, r.Region, r.Type)
 }
 
-func (r *ASG) Get() ([]resources.Item, error) {
+func (r *ASG) Get(ctx context.Context) ([]resources.Item, error) {
 	items := []resources.Item{}
-	var err error
-	var result *autoscaling.DescribeAutoScalingGroupsOutput
-
-	result, err = r.Client.DescribeAutoScalingGroups(context.TODO(), nil)
-	if err != nil {
-		match, _ := regexp.MatchString("does not exist", err.Error())
-		if !match {
-			slog.Debug("Error", "error", err)
+
+	paginator := autoscaling.NewDescribeAutoScalingGroupsPaginator(r.Client, &autoscaling.DescribeAutoScalingGroupsInput{
+		MaxRecords: r.PageSize(),
+	})
+
+	for paginator.HasMorePages() && !r.LimitReached(len(items)) {
+		result, err := paginator.NextPage(ctx)
+		if err != nil {
+			match, _ := regexp.MatchString("does not exist", err.Error())
+			if !match {
+				slog.Debug("Error", "error", err)
+			}
+			return items, err
 		}
-		return items, err
-	}
 
-	for _, item := range result.AutoScalingGroups {
+		for _, item := range result.AutoScalingGroups {
+
+			tags := []resources.ItemTag{}
+			for _, tag := range item.Tags {
+				newTag := resources.ItemTag{
+					Key:   *tag.Key,
+					Value: *tag.Value,
+				}
 
-		tags := []resources.ItemTag{}
-		for _, tag := range item.Tags {
-			newTag := resources.ItemTag{
-				Key:   *tag.Key,
-				Value: *tag.Value,
+				tags = append(tags, newTag)
 			}
 
-			tags = append(tags, newTag)
-		}
+			item := resources.Item{
+				ID:           *item.AutoScalingGroupName,
+				Name:         *item.AutoScalingGroupName,
+				Type:         "AutoScaling group",
+				ARN:          *item.AutoScalingGroupARN,
+				Tags:         tags,
+				Account:      r.AccountID,
+				AccountAlias: r.AccountAlias,
+				Region:       r.Region,
+				State:        aws.ToString(item.Status),
+				CreatedAt:    aws.ToTime(item.CreatedTime),
+			}
 
-		item := resources.Item{
-			Type:         "AutoScaling group",
-			ARN:          *item.AutoScalingGroupARN,
-			Tags:         tags,
-			Account:      r.AccountID,
-			AccountAlias: r.AccountAlias,
-			Region:       r.Region,
+			items = append(items, item)
 		}
-
-		items = append(items, item)
 	}
 
-	return items, nil
+	return r.Limit(items), nil
 }
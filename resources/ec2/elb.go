@@ -5,11 +5,31 @@ import (
 	"fmt"
 	"log/slog"
 	"regexp"
+	"slices"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/elasticloadbalancingv2"
+	"github.com/aws/aws-sdk-go-v2/service/elasticloadbalancingv2/types"
 	"github.com/wasilak/cloudpile/resources"
 )
 
+func init() {
+	resources.Register(resources.Collector{
+		Name:        "elb",
+		DisplayType: "ELB",
+		TaggingType: "elasticloadbalancing:loadbalancer",
+		New: func(cfg aws.Config, base resources.BaseAWSResource) resources.AWSResourceType {
+			return &ELB{
+				Client:          elasticloadbalancingv2.NewFromConfig(cfg),
+				BaseAWSResource: base,
+			}
+		},
+	})
+}
+
+// describeTagsBatchSize is the maximum number of ARNs accepted by DescribeTags.
+const describeTagsBatchSize = 20
+
 type ELB struct {
 	Client *elasticloadbalancingv2.Client
 	resources.BaseAWSResource
@@ -19,56 +39,111 @@ func (r *ELB) GetCacheKey() string {
 	return fmt.Sprintf("%s-%s-%s", r.AccountID, r.Region, r.Type)
 }
 
-func (r *ELB) Get() ([]resources.Item, error) {
+func (r *ELB) Get(ctx context.Context) ([]resources.Item, error) {
 	items := []resources.Item{}
-	var err error
-	var result *elasticloadbalancingv2.DescribeLoadBalancersOutput
-
-	result, err = r.Client.DescribeLoadBalancers(context.TODO(), nil)
-	if err != nil {
-		match, _ := regexp.MatchString("does not exist", err.Error())
-		if !match {
-			slog.Debug("Error", "error", err)
-		}
-		return items, err
-	}
+	loadBalancers := []types.LoadBalancer{}
 
-	for _, item := range result.LoadBalancers {
+	paginator := elasticloadbalancingv2.NewDescribeLoadBalancersPaginator(r.Client, &elasticloadbalancingv2.DescribeLoadBalancersInput{
+		PageSize: r.PageSize(),
+	})
 
-		describeTagsInput := elasticloadbalancingv2.DescribeTagsInput{
-			ResourceArns: []string{
-				*item.LoadBalancerArn,
-			},
+	for paginator.HasMorePages() && !r.LimitReached(len(loadBalancers)) {
+		result, err := paginator.NextPage(ctx)
+		if err != nil {
+			match, _ := regexp.MatchString("does not exist", err.Error())
+			if !match {
+				slog.Debug("Error", "error", err)
+			}
+			return items, err
 		}
 
-		tagsOutput, err := r.Client.DescribeTags(context.TODO(), &describeTagsInput, func(*elasticloadbalancingv2.Options) {})
+		loadBalancers = append(loadBalancers, result.LoadBalancers...)
+	}
+
+	if r.LimitReached(len(loadBalancers)) {
+		loadBalancers = loadBalancers[:r.MaxItems]
+	}
+
+	tags := map[string][]resources.ItemTag{}
+	if !r.SkipTags {
+		var err error
+		tags, err = r.describeTags(ctx, loadBalancers)
 		if err != nil {
 			return items, err
 		}
+	}
 
-		tags := []resources.ItemTag{}
-		for _, item := range tagsOutput.TagDescriptions {
-			for _, tag := range item.Tags {
-				newTag := resources.ItemTag{
-					Key:   *tag.Key,
-					Value: *tag.Value,
-				}
+	for _, item := range loadBalancers {
 
-				tags = append(tags, newTag)
+		itemTags, ok := tags[*item.LoadBalancerArn]
+		if !ok {
+			itemTags = []resources.ItemTag{}
+		}
+
+		subnets := []string{}
+		for _, zone := range item.AvailabilityZones {
+			if zone.SubnetId != nil {
+				subnets = append(subnets, *zone.SubnetId)
 			}
 		}
 
+		state := ""
+		if item.State != nil {
+			state = string(item.State.Code)
+		}
+
 		item := resources.Item{
+			ID:             *item.LoadBalancerName,
+			ARN:            *item.LoadBalancerArn,
+			Name:           *item.LoadBalancerName,
 			Type:           fmt.Sprintf("ELB (%s)", item.Type),
-			Tags:           tags,
+			Tags:           itemTags,
 			Account:        r.AccountID,
 			AccountAlias:   r.AccountAlias,
 			Region:         r.Region,
 			PrivateDNSName: *item.DNSName,
+			VpcID:          aws.ToString(item.VpcId),
+			State:          state,
+			CreatedAt:      aws.ToTime(item.CreatedTime),
 		}
 
+		item.SetSubnets(subnets)
+
 		items = append(items, item)
 	}
 
 	return items, nil
 }
+
+// describeTags fetches tags of load balancers in batches of describeTagsBatchSize ARNs.
+func (r *ELB) describeTags(ctx context.Context, loadBalancers []types.LoadBalancer) (map[string][]resources.ItemTag, error) {
+	tags := map[string][]resources.ItemTag{}
+
+	for batch := range slices.Chunk(loadBalancers, describeTagsBatchSize) {
+		describeTagsInput := elasticloadbalancingv2.DescribeTagsInput{}
+		for _, item := range batch {
+			describeTagsInput.ResourceArns = append(describeTagsInput.ResourceArns, *item.LoadBalancerArn)
+		}
+
+		tagsOutput, err := r.Client.DescribeTags(ctx, &describeTagsInput)
+		if err != nil {
+			return tags, err
+		}
+
+		for _, description := range tagsOutput.TagDescriptions {
+			itemTags := []resources.ItemTag{}
+			for _, tag := range description.Tags {
+				newTag := resources.ItemTag{
+					Key:   *tag.Key,
+					Value: *tag.Value,
+				}
+
+				itemTags = append(itemTags, newTag)
+			}
+
+			tags[*description.ResourceArn] = itemTags
+		}
+	}
+
+	return tags, nil
+}
@@ -0,0 +1,138 @@
+package ec2
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"regexp"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/wasilak/cloudpile/resources"
+)
+
+func init() {
+	resources.Register(resources.Collector{
+		Name:        "eni",
+		DisplayType: "Network interface",
+		New: func(cfg aws.Config, base resources.BaseAWSResource) resources.AWSResourceType {
+			return &ENI{
+				Client:          ec2.NewFromConfig(cfg),
+				BaseAWSResource: base,
+			}
+		},
+	})
+}
+
+// ENI collects network interfaces, which tell who owns an IP address even
+// when it is not an EC2 instance, e.g. Lambda in VPC, RDS, NAT gateway or ELB node.
+type ENI struct {
+	Client *ec2.Client
+	resources.BaseAWSResource
+}
+
+func (r *ENI) GetCacheKey() string {
+	return fmt.Sprintf("%s-%s-%s", r.AccountID, r.Region, r.Type)
+}
+
+func (r *ENI) Get(ctx context.Context) ([]resources.Item, error) {
+	items := []resources.Item{}
+
+	paginator := ec2.NewDescribeNetworkInterfacesPaginator(r.Client, &ec2.DescribeNetworkInterfacesInput{
+		MaxResults: r.PageSize(),
+	})
+
+	for paginator.HasMorePages() && !r.LimitReached(len(items)) {
+		result, err := paginator.NextPage(ctx)
+		if err != nil {
+			match, _ := regexp.MatchString("does not exist", err.Error())
+			if !match {
+				slog.Debug("Error", "error", err)
+			}
+			return items, err
+		}
+
+		for _, networkInterface := range result.NetworkInterfaces {
+
+			tags := []resources.ItemTag{}
+			for _, v := range networkInterface.TagSet {
+				newTag := resources.ItemTag{
+					Key:   *v.Key,
+					Value: *v.Value,
+				}
+
+				tags = append(tags, newTag)
+			}
+
+			item := resources.Item{
+				ID:             *networkInterface.NetworkInterfaceId,
+				ARN:            ec2ARN(r.Region, networkInterface.OwnerId, "network-interface/"+*networkInterface.NetworkInterfaceId),
+				Type:           fmt.Sprintf("Network interface (%s)", networkInterface.InterfaceType),
+				Tags:           tags,
+				Account:        r.AccountID,
+				AccountAlias:   r.AccountAlias,
+				Region:         r.Region,
+				IP:             aws.ToString(networkInterface.PrivateIpAddress),
+				PrivateDNSName: aws.ToString(networkInterface.PrivateDnsName),
+				VpcID:          aws.ToString(networkInterface.VpcId),
+				SubnetID:       aws.ToString(networkInterface.SubnetId),
+				State:          string(networkInterface.Status),
+				Details:        interfaceDetails(networkInterface),
+			}
+
+			addInterfaceAddresses(&item, networkInterface)
+
+			items = append(items, item)
+		}
+	}
+
+	return r.Limit(items), nil
+}
+
+// interfaceDetails describes what the interface belongs to.
+func interfaceDetails(networkInterface types.NetworkInterface) map[string]string {
+	details := map[string]string{
+		"interface_type": string(networkInterface.InterfaceType),
+		"description":    aws.ToString(networkInterface.Description),
+		"requester":      aws.ToString(networkInterface.RequesterId),
+	}
+
+	if attachment := networkInterface.Attachment; attachment != nil {
+		details["attachment_id"] = aws.ToString(attachment.AttachmentId)
+		details["instance_id"] = aws.ToString(attachment.InstanceId)
+		details["instance_owner"] = aws.ToString(attachment.InstanceOwnerId)
+	}
+
+	// drop empty values, so that only known facts are shown
+	for k, v := range details {
+		if v == "" {
+			delete(details, k)
+		}
+	}
+
+	return details
+}
+
+// addInterfaceAddresses adds all private, public and IPv6 addresses of interface to item.
+func addInterfaceAddresses(item *resources.Item, networkInterface types.NetworkInterface) {
+	for _, address := range networkInterface.PrivateIpAddresses {
+		kind := resources.AddressSecondary
+		if aws.ToBool(address.Primary) {
+			kind = resources.AddressPrivate
+		}
+		item.AddAddress(aws.ToString(address.PrivateIpAddress), kind)
+
+		if address.Association != nil {
+			item.AddAddress(aws.ToString(address.Association.PublicIp), publicAddressKind(address.Association.IpOwnerId))
+		}
+	}
+
+	if networkInterface.Association != nil {
+		item.AddAddress(aws.ToString(networkInterface.Association.PublicIp), publicAddressKind(networkInterface.Association.IpOwnerId))
+	}
+
+	for _, address := range networkInterface.Ipv6Addresses {
+		item.AddAddress(aws.ToString(address.Ipv6Address), resources.AddressIPv6)
+	}
+}
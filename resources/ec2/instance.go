@@ -6,10 +6,25 @@ import (
 	"log/slog"
 	"regexp"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
 	"github.com/wasilak/cloudpile/resources"
 )
 
+func init() {
+	resources.Register(resources.Collector{
+		Name:        "ec2",
+		DisplayType: "EC2 instance",
+		New: func(cfg aws.Config, base resources.BaseAWSResource) resources.AWSResourceType {
+			return &EC2Instance{
+				Client:          ec2.NewFromConfig(cfg),
+				BaseAWSResource: base,
+			}
+		},
+	})
+}
+
 type EC2Instance struct {
 	Client *ec2.Client
 	resources.BaseAWSResource
@@ -19,54 +34,112 @@ func (r *EC2Instance) GetCacheKey() string {
 	return fmt.Sprintf("%s-%s-%s", r.AccountID, r.Region, r.Type)
 }
 
-func (r *EC2Instance) Get() ([]resources.Item, error) {
+func (r *EC2Instance) Get(ctx context.Context) ([]resources.Item, error) {
 	items := []resources.Item{}
-	var err error
-	var result *ec2.DescribeInstancesOutput
-
-	// Call to get detailed information on each instance
-	result, err = r.Client.DescribeInstances(context.TODO(), nil)
-	if err != nil {
-		match, _ := regexp.MatchString("does not exist", err.Error())
-		if !match {
-			slog.Debug("Error", "error", err)
+
+	paginator := ec2.NewDescribeInstancesPaginator(r.Client, &ec2.DescribeInstancesInput{
+		MaxResults: r.PageSize(),
+	})
+
+	for paginator.HasMorePages() && !r.LimitReached(len(items)) {
+		// Call to get detailed information on each instance
+		result, err := paginator.NextPage(ctx)
+		if err != nil {
+			match, _ := regexp.MatchString("does not exist", err.Error())
+			if !match {
+				slog.Debug("Error", "error", err)
+			}
+			return items, err
 		}
-		return items, err
-	}
 
-	for _, reservation := range result.Reservations {
-		for _, instance := range reservation.Instances {
+		for _, reservation := range result.Reservations {
+			for _, instance := range reservation.Instances {
 
-			privateIP := ""
+				privateIP := ""
 
-			if instance.PrivateIpAddress != nil {
-				privateIP = *instance.PrivateIpAddress
-			}
+				if instance.PrivateIpAddress != nil {
+					privateIP = *instance.PrivateIpAddress
+				}
+
+				tags := []resources.ItemTag{}
+				for _, v := range instance.Tags {
+					newTag := resources.ItemTag{
+						Key:   *v.Key,
+						Value: *v.Value,
+					}
+
+					tags = append(tags, newTag)
+				}
 
-			tags := []resources.ItemTag{}
-			for _, v := range instance.Tags {
-				newTag := resources.ItemTag{
-					Key:   *v.Key,
-					Value: *v.Value,
+				item := resources.Item{
+					ID:             *instance.InstanceId,
+					ARN:            ec2ARN(r.Region, reservation.OwnerId, "instance/"+*instance.InstanceId),
+					Type:           "EC2 instance",
+					Tags:           tags,
+					Account:        r.AccountID,
+					AccountAlias:   r.AccountAlias,
+					Region:         r.Region,
+					IP:             privateIP,
+					PrivateDNSName: *instance.PrivateDnsName,
+					VpcID:          aws.ToString(instance.VpcId),
+					SubnetID:       aws.ToString(instance.SubnetId),
+					CreatedAt:      aws.ToTime(instance.LaunchTime),
 				}
 
-				tags = append(tags, newTag)
+				if instance.State != nil {
+					item.State = string(instance.State.Name)
+				}
+
+				addInstanceAddresses(&item, instance)
+
+				items = append(items, item)
 			}
+		}
+	}
+
+	return r.Limit(items), nil
+}
 
-			item := resources.Item{
-				ID:             *instance.InstanceId,
-				Type:           "EC2 instance",
-				Tags:           tags,
-				Account:        r.AccountID,
-				AccountAlias:   r.AccountAlias,
-				Region:         r.Region,
-				IP:             privateIP,
-				PrivateDNSName: *instance.PrivateDnsName,
+// addInstanceAddresses adds addresses of all instance network interfaces to item.
+func addInstanceAddresses(item *resources.Item, instance types.Instance) {
+	item.AddAddress(aws.ToString(instance.PrivateIpAddress), resources.AddressPrivate)
+
+	for _, networkInterface := range instance.NetworkInterfaces {
+		primaryInterface := networkInterface.Attachment != nil && aws.ToInt32(networkInterface.Attachment.DeviceIndex) == 0
+
+		for _, address := range networkInterface.PrivateIpAddresses {
+			kind := resources.AddressSecondary
+			if primaryInterface && aws.ToBool(address.Primary) {
+				kind = resources.AddressPrivate
 			}
+			item.AddAddress(aws.ToString(address.PrivateIpAddress), kind)
 
-			items = append(items, item)
+			if address.Association != nil {
+				item.AddAddress(aws.ToString(address.Association.PublicIp), publicAddressKind(address.Association.IpOwnerId))
+			}
+		}
+
+		for _, address := range networkInterface.Ipv6Addresses {
+			item.AddAddress(aws.ToString(address.Ipv6Address), resources.AddressIPv6)
 		}
 	}
 
-	return items, nil
+	item.AddAddress(aws.ToString(instance.PublicIpAddress), resources.AddressPublic)
+}
+
+// publicAddressKind tells auto-assigned public addresses, owned by amazon,
+// from elastic IPs owned by the account.
+func publicAddressKind(ownerID *string) string {
+	if aws.ToString(ownerID) == "amazon" {
+		return resources.AddressPublic
+	}
+
+	return resources.AddressElastic
+}
+
+// ec2ARN returns ARN of an EC2 resource owned by ownerID. Describe calls also
+// return resources shared with the account, so the owner is not always the
+// collecting account.
+func ec2ARN(region string, ownerID *string, resource string) string {
+	return fmt.Sprintf("arn:%s:ec2:%s:%s:%s", resources.Partition(region), region, aws.ToString(ownerID), resource)
 }
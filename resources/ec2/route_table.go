@@ -0,0 +1,139 @@
+package ec2
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/wasilak/cloudpile/resources"
+)
+
+func init() {
+	resources.Register(resources.Collector{
+		Name:        "route_table",
+		DisplayType: "Route table",
+		New: func(cfg aws.Config, base resources.BaseAWSResource) resources.AWSResourceType {
+			return &RouteTable{
+				Client:          ec2.NewFromConfig(cfg),
+				BaseAWSResource: base,
+			}
+		},
+	})
+}
+
+type RouteTable struct {
+	Client *ec2.Client
+	resources.BaseAWSResource
+}
+
+func (r *RouteTable) GetCacheKey() string {
+	return fmt.Sprintf("%s-%s-%s", r.AccountID, r.Region, r.Type)
+}
+
+func (r *RouteTable) Get(ctx context.Context) ([]resources.Item, error) {
+	items := []resources.Item{}
+
+	paginator := ec2.NewDescribeRouteTablesPaginator(r.Client, &ec2.DescribeRouteTablesInput{
+		MaxResults: r.PageSize(),
+	})
+
+	for paginator.HasMorePages() && !r.LimitReached(len(items)) {
+		result, err := paginator.NextPage(ctx)
+		if err != nil {
+			match, _ := regexp.MatchString("does not exist", err.Error())
+			if !match {
+				slog.Debug("Error", "error", err)
+			}
+			return items, err
+		}
+
+		for _, routeTable := range result.RouteTables {
+
+			tags := []resources.ItemTag{}
+			for _, v := range routeTable.Tags {
+				newTag := resources.ItemTag{
+					Key:   *v.Key,
+					Value: *v.Value,
+				}
+
+				tags = append(tags, newTag)
+			}
+
+			main := false
+			subnets := []string{}
+			for _, association := range routeTable.Associations {
+				main = main || aws.ToBool(association.Main)
+				if association.SubnetId != nil {
+					subnets = append(subnets, *association.SubnetId)
+				}
+			}
+
+			routes := []string{}
+			for _, route := range routeTable.Routes {
+				routes = append(routes, fmt.Sprintf("%s -> %s", routeDestination(route), routeTarget(route)))
+			}
+
+			item := resources.Item{
+				ID:           *routeTable.RouteTableId,
+				ARN:          ec2ARN(r.Region, routeTable.OwnerId, "route-table/"+*routeTable.RouteTableId),
+				Type:         "Route table",
+				Tags:         tags,
+				Account:      r.AccountID,
+				AccountAlias: r.AccountAlias,
+				Region:       r.Region,
+				VpcID:        aws.ToString(routeTable.VpcId),
+				Details: map[string]string{
+					"main":   strconv.FormatBool(main),
+					"routes": strings.Join(routes, ", "),
+				},
+			}
+
+			if len(subnets) > 0 {
+				item.Details["subnets"] = strings.Join(subnets, ",")
+			}
+
+			items = append(items, item)
+		}
+	}
+
+	return r.Limit(items), nil
+}
+
+func routeDestination(route types.Route) string {
+	switch {
+	case route.DestinationCidrBlock != nil:
+		return *route.DestinationCidrBlock
+	case route.DestinationIpv6CidrBlock != nil:
+		return *route.DestinationIpv6CidrBlock
+	}
+
+	return aws.ToString(route.DestinationPrefixListId)
+}
+
+// routeTarget returns ID of whatever the route sends traffic to.
+func routeTarget(route types.Route) string {
+	for _, target := range []*string{
+		route.GatewayId,
+		route.NatGatewayId,
+		route.TransitGatewayId,
+		route.VpcPeeringConnectionId,
+		route.NetworkInterfaceId,
+		route.InstanceId,
+		route.EgressOnlyInternetGatewayId,
+		route.LocalGatewayId,
+		route.CarrierGatewayId,
+		route.CoreNetworkArn,
+	} {
+		if target != nil {
+			return *target
+		}
+	}
+
+	return "unknown"
+}
@@ -6,10 +6,24 @@ import (
 	"log/slog"
 	"regexp"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/ec2"
 	"github.com/wasilak/cloudpile/resources"
 )
 
+func init() {
+	resources.Register(resources.Collector{
+		Name:        "sg",
+		DisplayType: "Security Group",
+		New: func(cfg aws.Config, base resources.BaseAWSResource) resources.AWSResourceType {
+			return &EC2Sg{
+				Client:          ec2.NewFromConfig(cfg),
+				BaseAWSResource: base,
+			}
+		},
+	})
+}
+
 type EC2Sg struct {
 	Client *ec2.Client
 	resources.BaseAWSResource
@@ -19,43 +33,54 @@ func (r *EC2Sg) GetCacheKey() string {
 	return fmt.Sprintf("%s-%s-%s", r.AccountID, r.Region, r.Type)
 }
 
-func (r *EC2Sg) Get() ([]resources.Item, error) {
+func (r *EC2Sg) Get(ctx context.Context) ([]resources.Item, error) {
 	var items []resources.Item
-	var err error
-	var result *ec2.DescribeSecurityGroupsOutput
-
-	result, err = r.Client.DescribeSecurityGroups(context.TODO(), nil)
-	if err != nil {
-		match, _ := regexp.MatchString("does not exist", err.Error())
-		if !match {
-			slog.Debug("Error", "error", err)
+
+	paginator := ec2.NewDescribeSecurityGroupsPaginator(r.Client, &ec2.DescribeSecurityGroupsInput{
+		MaxResults: r.PageSize(),
+	})
+
+	for paginator.HasMorePages() && !r.LimitReached(len(items)) {
+		result, err := paginator.NextPage(ctx)
+		if err != nil {
+			match, _ := regexp.MatchString("does not exist", err.Error())
+			if !match {
+				slog.Debug("Error", "error", err)
+			}
+			return items, err
 		}
-		return items, err
-	}
 
-	for _, sg := range result.SecurityGroups {
+		for _, sg := range result.SecurityGroups {
 
-		tags := []resources.ItemTag{}
-		for _, v := range sg.Tags {
-			newTag := resources.ItemTag{
-				Key:   *v.Key,
-				Value: *v.Value,
+			tags := []resources.ItemTag{}
+			for _, v := range sg.Tags {
+				newTag := resources.ItemTag{
+					Key:   *v.Key,
+					Value: *v.Value,
+				}
+
+				tags = append(tags, newTag)
 			}
 
-			tags = append(tags, newTag)
-		}
+			item := resources.Item{
+				ID:           *sg.GroupId,
+				ARN:          aws.ToString(sg.SecurityGroupArn),
+				Name:         aws.ToString(sg.GroupName),
+				Type:         "Security Group",
+				Tags:         tags,
+				Account:      r.AccountID,
+				AccountAlias: r.AccountAlias,
+				Region:       r.Region,
+				VpcID:        aws.ToString(sg.VpcId),
+			}
 
-		item := resources.Item{
-			ID:           *sg.GroupId,
-			Type:         "Security Group",
-			Tags:         tags,
-			Account:      r.AccountID,
-			AccountAlias: r.AccountAlias,
-			Region:       r.Region,
-		}
+			if description := aws.ToString(sg.Description); description != "" {
+				item.Details = map[string]string{"description": description}
+			}
 
-		items = append(items, item)
+			items = append(items, item)
+		}
 	}
 
-	return items, nil
+	return r.Limit(items), nil
 }
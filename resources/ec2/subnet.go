@@ -0,0 +1,96 @@
+package ec2
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strconv"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/wasilak/cloudpile/resources"
+)
+
+func init() {
+	resources.Register(resources.Collector{
+		Name:        "subnet",
+		DisplayType: "Subnet",
+		New: func(cfg aws.Config, base resources.BaseAWSResource) resources.AWSResourceType {
+			return &Subnet{
+				Client:          ec2.NewFromConfig(cfg),
+				BaseAWSResource: base,
+			}
+		},
+	})
+}
+
+type Subnet struct {
+	Client *ec2.Client
+	resources.BaseAWSResource
+}
+
+func (r *Subnet) GetCacheKey() string {
+	return fmt.Sprintf("%s-%s-%s", r.AccountID, r.Region, r.Type)
+}
+
+func (r *Subnet) Get(ctx context.Context) ([]resources.Item, error) {
+	items := []resources.Item{}
+
+	paginator := ec2.NewDescribeSubnetsPaginator(r.Client, &ec2.DescribeSubnetsInput{
+		MaxResults: r.PageSize(),
+	})
+
+	for paginator.HasMorePages() && !r.LimitReached(len(items)) {
+		result, err := paginator.NextPage(ctx)
+		if err != nil {
+			match, _ := regexp.MatchString("does not exist", err.Error())
+			if !match {
+				slog.Debug("Error", "error", err)
+			}
+			return items, err
+		}
+
+		for _, subnet := range result.Subnets {
+
+			tags := []resources.ItemTag{}
+			for _, v := range subnet.Tags {
+				newTag := resources.ItemTag{
+					Key:   *v.Key,
+					Value: *v.Value,
+				}
+
+				tags = append(tags, newTag)
+			}
+
+			item := resources.Item{
+				ID:           *subnet.SubnetId,
+				ARN:          aws.ToString(subnet.SubnetArn),
+				Type:         "Subnet",
+				Tags:         tags,
+				Account:      r.AccountID,
+				AccountAlias: r.AccountAlias,
+				Region:       r.Region,
+				VpcID:        aws.ToString(subnet.VpcId),
+				SubnetID:     *subnet.SubnetId,
+				State:        string(subnet.State),
+				Details: map[string]string{
+					"availability_zone":   aws.ToString(subnet.AvailabilityZone),
+					"available_ips":       strconv.Itoa(int(aws.ToInt32(subnet.AvailableIpAddressCount))),
+					"public_ip_on_launch": strconv.FormatBool(aws.ToBool(subnet.MapPublicIpOnLaunch)),
+				},
+			}
+
+			if subnet.CidrBlock != nil {
+				item.CIDRs = append(item.CIDRs, *subnet.CidrBlock)
+			}
+			for _, association := range subnet.Ipv6CidrBlockAssociationSet {
+				item.CIDRs = append(item.CIDRs, aws.ToString(association.Ipv6CidrBlock))
+			}
+
+			items = append(items, item)
+		}
+	}
+
+	return r.Limit(items), nil
+}
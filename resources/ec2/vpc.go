@@ -0,0 +1,93 @@
+package ec2
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strconv"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/wasilak/cloudpile/resources"
+)
+
+func init() {
+	resources.Register(resources.Collector{
+		Name:        "vpc",
+		DisplayType: "VPC",
+		New: func(cfg aws.Config, base resources.BaseAWSResource) resources.AWSResourceType {
+			return &VPC{
+				Client:          ec2.NewFromConfig(cfg),
+				BaseAWSResource: base,
+			}
+		},
+	})
+}
+
+type VPC struct {
+	Client *ec2.Client
+	resources.BaseAWSResource
+}
+
+func (r *VPC) GetCacheKey() string {
+	return fmt.Sprintf("%s-%s-%s", r.AccountID, r.Region, r.Type)
+}
+
+func (r *VPC) Get(ctx context.Context) ([]resources.Item, error) {
+	items := []resources.Item{}
+
+	paginator := ec2.NewDescribeVpcsPaginator(r.Client, &ec2.DescribeVpcsInput{
+		MaxResults: r.PageSize(),
+	})
+
+	for paginator.HasMorePages() && !r.LimitReached(len(items)) {
+		result, err := paginator.NextPage(ctx)
+		if err != nil {
+			match, _ := regexp.MatchString("does not exist", err.Error())
+			if !match {
+				slog.Debug("Error", "error", err)
+			}
+			return items, err
+		}
+
+		for _, vpc := range result.Vpcs {
+
+			tags := []resources.ItemTag{}
+			for _, v := range vpc.Tags {
+				newTag := resources.ItemTag{
+					Key:   *v.Key,
+					Value: *v.Value,
+				}
+
+				tags = append(tags, newTag)
+			}
+
+			item := resources.Item{
+				ID:           *vpc.VpcId,
+				ARN:          ec2ARN(r.Region, vpc.OwnerId, "vpc/"+*vpc.VpcId),
+				Type:         "VPC",
+				Tags:         tags,
+				Account:      r.AccountID,
+				AccountAlias: r.AccountAlias,
+				Region:       r.Region,
+				VpcID:        *vpc.VpcId,
+				State:        string(vpc.State),
+				Details: map[string]string{
+					"default": strconv.FormatBool(aws.ToBool(vpc.IsDefault)),
+				},
+			}
+
+			for _, association := range vpc.CidrBlockAssociationSet {
+				item.CIDRs = append(item.CIDRs, aws.ToString(association.CidrBlock))
+			}
+			for _, association := range vpc.Ipv6CidrBlockAssociationSet {
+				item.CIDRs = append(item.CIDRs, aws.ToString(association.Ipv6CidrBlock))
+			}
+
+			items = append(items, item)
+		}
+	}
+
+	return r.Limit(items), nil
+}
@@ -0,0 +1,273 @@
+package resources
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ecs"
+	"github.com/aws/aws-sdk-go-v2/service/ecs/types"
+)
+
+const (
+	// describeServicesBatchSize is the maximum number of services per DescribeServices call.
+	describeServicesBatchSize = 10
+	// describeBatchSize is the maximum number of clusters or tasks per describe call.
+	describeBatchSize = 100
+)
+
+func init() {
+	Register(Collector{
+		Name:        "ecs",
+		DisplayType: "ECS",
+		New: func(cfg aws.Config, base BaseAWSResource) AWSResourceType {
+			return &ECS{
+				Client:          ecs.NewFromConfig(cfg),
+				BaseAWSResource: base,
+			}
+		},
+	})
+}
+
+// ECS collects container clusters, their services and running tasks. Tasks
+// in awsvpc network mode get the addresses of their network interface.
+type ECS struct {
+	Client *ecs.Client
+	BaseAWSResource
+}
+
+func (r *ECS) GetCacheKey() string {
+	return fmt.Sprintf("%s-%s-%s", r.AccountID, r.Region, r.Type)
+}
+
+func (r *ECS) Get(ctx context.Context) ([]Item, error) {
+	items := []Item{}
+
+	paginator := ecs.NewListClustersPaginator(r.Client, &ecs.ListClustersInput{
+		MaxResults: r.PageSize(),
+	})
+
+	for paginator.HasMorePages() && !r.LimitReached(len(items)) {
+		result, err := paginator.NextPage(ctx)
+		if err != nil {
+			return items, err
+		}
+
+		for batch := range slices.Chunk(result.ClusterArns, describeBatchSize) {
+			clusters, err := r.Client.DescribeClusters(ctx, &ecs.DescribeClustersInput{
+				Clusters: batch,
+				Include:  []types.ClusterField{types.ClusterFieldTags},
+			})
+			if err != nil {
+				return items, err
+			}
+
+			for _, cluster := range clusters.Clusters {
+				items = append(items, r.clusterItem(cluster))
+
+				services, err := r.services(ctx, cluster.ClusterArn)
+				if err != nil {
+					return items, err
+				}
+				items = append(items, services...)
+
+				tasks, err := r.tasks(ctx, cluster.ClusterArn)
+				if err != nil {
+					return items, err
+				}
+				items = append(items, tasks...)
+			}
+		}
+	}
+
+	return r.Limit(items), nil
+}
+
+func (r *ECS) services(ctx context.Context, clusterArn *string) ([]Item, error) {
+	items := []Item{}
+
+	paginator := ecs.NewListServicesPaginator(r.Client, &ecs.ListServicesInput{
+		Cluster:    clusterArn,
+		MaxResults: r.PageSize(),
+	})
+
+	for paginator.HasMorePages() {
+		result, err := paginator.NextPage(ctx)
+		if err != nil {
+			return items, err
+		}
+
+		for batch := range slices.Chunk(result.ServiceArns, describeServicesBatchSize) {
+			services, err := r.Client.DescribeServices(ctx, &ecs.DescribeServicesInput{
+				Cluster:  clusterArn,
+				Services: batch,
+				Include:  []types.ServiceField{types.ServiceFieldTags},
+			})
+			if err != nil {
+				return items, err
+			}
+
+			for _, service := range services.Services {
+				items = append(items, r.serviceItem(service))
+			}
+		}
+	}
+
+	return items, nil
+}
+
+func (r *ECS) tasks(ctx context.Context, clusterArn *string) ([]Item, error) {
+	items := []Item{}
+
+	paginator := ecs.NewListTasksPaginator(r.Client, &ecs.ListTasksInput{
+		Cluster:       clusterArn,
+		DesiredStatus: types.DesiredStatusRunning,
+		MaxResults:    r.PageSize(),
+	})
+
+	for paginator.HasMorePages() {
+		result, err := paginator.NextPage(ctx)
+		if err != nil {
+			return items, err
+		}
+
+		for batch := range slices.Chunk(result.TaskArns, describeBatchSize) {
+			tasks, err := r.Client.DescribeTasks(ctx, &ecs.DescribeTasksInput{
+				Cluster: clusterArn,
+				Tasks:   batch,
+				Include: []types.TaskField{types.TaskFieldTags},
+			})
+			if err != nil {
+				return items, err
+			}
+
+			for _, task := range tasks.Tasks {
+				items = append(items, r.taskItem(task))
+			}
+		}
+	}
+
+	return items, nil
+}
+
+func (r *ECS) clusterItem(cluster types.Cluster) Item {
+	return Item{
+		ID:           aws.ToString(cluster.ClusterName),
+		ARN:          aws.ToString(cluster.ClusterArn),
+		Name:         aws.ToString(cluster.ClusterName),
+		Type:         "ECS cluster",
+		Tags:         ecsTags(cluster.Tags),
+		Account:      r.AccountID,
+		AccountAlias: r.AccountAlias,
+		Region:       r.Region,
+		State:        aws.ToString(cluster.Status),
+		Details: map[string]string{
+			"services":        fmt.Sprint(cluster.ActiveServicesCount),
+			"running_tasks":   fmt.Sprint(cluster.RunningTasksCount),
+			"container_hosts": fmt.Sprint(cluster.RegisteredContainerInstancesCount),
+		},
+	}
+}
+
+func (r *ECS) serviceItem(service types.Service) Item {
+	details := map[string]string{
+		"cluster":         path.Base(aws.ToString(service.ClusterArn)),
+		"launch_type":     string(service.LaunchType),
+		"task_definition": path.Base(aws.ToString(service.TaskDefinition)),
+		"desired":         fmt.Sprint(service.DesiredCount),
+		"running":         fmt.Sprint(service.RunningCount),
+	}
+	if details["launch_type"] == "" {
+		delete(details, "launch_type")
+	}
+
+	return Item{
+		ID:           aws.ToString(service.ServiceName),
+		ARN:          aws.ToString(service.ServiceArn),
+		Name:         aws.ToString(service.ServiceName),
+		Type:         "ECS service",
+		Tags:         ecsTags(service.Tags),
+		Account:      r.AccountID,
+		AccountAlias: r.AccountAlias,
+		Region:       r.Region,
+		State:        aws.ToString(service.Status),
+		CreatedAt:    aws.ToTime(service.CreatedAt),
+		Details:      details,
+	}
+}
+
+func (r *ECS) taskItem(task types.Task) Item {
+	details := map[string]string{
+		"cluster":           path.Base(aws.ToString(task.ClusterArn)),
+		"launch_type":       string(task.LaunchType),
+		"task_definition":   path.Base(aws.ToString(task.TaskDefinitionArn)),
+		"availability_zone": aws.ToString(task.AvailabilityZone),
+	}
+
+	// group of service tasks is "service:<name>"
+	if service, ok := strings.CutPrefix(aws.ToString(task.Group), "service:"); ok {
+		details["service"] = service
+	}
+
+	item := Item{
+		ID:           path.Base(aws.ToString(task.TaskArn)),
+		ARN:          aws.ToString(task.TaskArn),
+		Type:         "ECS task",
+		Tags:         ecsTags(task.Tags),
+		Account:      r.AccountID,
+		AccountAlias: r.AccountAlias,
+		Region:       r.Region,
+		State:        aws.ToString(task.LastStatus),
+		CreatedAt:    aws.ToTime(task.CreatedAt),
+		Details:      details,
+	}
+
+	for _, attachment := range task.Attachments {
+		if aws.ToString(attachment.Type) != "ElasticNetworkInterface" {
+			continue
+		}
+
+		for _, detail := range attachment.Details {
+			value := aws.ToString(detail.Value)
+
+			switch aws.ToString(detail.Name) {
+			case "privateIPv4Address":
+				if item.IP == "" {
+					item.IP = value
+				}
+				item.AddAddress(value, AddressPrivate)
+			case "ipv6Address":
+				item.AddAddress(value, AddressIPv6)
+			case "privateDnsName":
+				item.PrivateDNSName = value
+			case "subnetId":
+				item.SubnetID = value
+			case "networkInterfaceId":
+				details["network_interface"] = value
+			}
+		}
+	}
+
+	for k, v := range details {
+		if v == "" {
+			delete(details, k)
+		}
+	}
+
+	return item
+}
+
+func ecsTags(tagList []types.Tag) []ItemTag {
+	tags := []ItemTag{}
+	for _, tag := range tagList {
+		tags = append(tags, ItemTag{
+			Key:   aws.ToString(tag.Key),
+			Value: aws.ToString(tag.Value),
+		})
+	}
+
+	return tags
+}
@@ -0,0 +1,179 @@
+package resources
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/eks"
+	"github.com/aws/aws-sdk-go-v2/service/eks/types"
+)
+
+func init() {
+	Register(Collector{
+		Name:        "eks",
+		DisplayType: "EKS",
+		New: func(cfg aws.Config, base BaseAWSResource) AWSResourceType {
+			return &EKS{
+				Client:          eks.NewFromConfig(cfg),
+				BaseAWSResource: base,
+			}
+		},
+	})
+}
+
+// EKS collects Kubernetes clusters and their managed node groups.
+type EKS struct {
+	Client *eks.Client
+	BaseAWSResource
+}
+
+func (r *EKS) GetCacheKey() string {
+	return fmt.Sprintf("%s-%s-%s", r.AccountID, r.Region, r.Type)
+}
+
+func (r *EKS) Get(ctx context.Context) ([]Item, error) {
+	items := []Item{}
+
+	paginator := eks.NewListClustersPaginator(r.Client, &eks.ListClustersInput{
+		MaxResults: r.PageSize(),
+	})
+
+	for paginator.HasMorePages() && !r.LimitReached(len(items)) {
+		result, err := paginator.NextPage(ctx)
+		if err != nil {
+			return items, err
+		}
+
+		for _, name := range result.Clusters {
+			cluster, err := r.Client.DescribeCluster(ctx, &eks.DescribeClusterInput{
+				Name: aws.String(name),
+			})
+			if err != nil {
+				return items, err
+			}
+
+			items = append(items, r.clusterItem(*cluster.Cluster))
+
+			nodegroups, err := r.nodegroups(ctx, name)
+			if err != nil {
+				return items, err
+			}
+
+			items = append(items, nodegroups...)
+		}
+	}
+
+	return r.Limit(items), nil
+}
+
+func (r *EKS) nodegroups(ctx context.Context, clusterName string) ([]Item, error) {
+	items := []Item{}
+
+	paginator := eks.NewListNodegroupsPaginator(r.Client, &eks.ListNodegroupsInput{
+		ClusterName: aws.String(clusterName),
+		MaxResults:  r.PageSize(),
+	})
+
+	for paginator.HasMorePages() {
+		result, err := paginator.NextPage(ctx)
+		if err != nil {
+			return items, err
+		}
+
+		for _, name := range result.Nodegroups {
+			nodegroup, err := r.Client.DescribeNodegroup(ctx, &eks.DescribeNodegroupInput{
+				ClusterName:   aws.String(clusterName),
+				NodegroupName: aws.String(name),
+			})
+			if err != nil {
+				return items, err
+			}
+
+			items = append(items, r.nodegroupItem(*nodegroup.Nodegroup))
+		}
+	}
+
+	return items, nil
+}
+
+func (r *EKS) clusterItem(cluster types.Cluster) Item {
+	item := Item{
+		ID:           aws.ToString(cluster.Name),
+		ARN:          aws.ToString(cluster.Arn),
+		Name:         aws.ToString(cluster.Name),
+		Type:         "EKS cluster",
+		Tags:         mapTags(cluster.Tags),
+		Account:      r.AccountID,
+		AccountAlias: r.AccountAlias,
+		Region:       r.Region,
+		State:        string(cluster.Status),
+		CreatedAt:    aws.ToTime(cluster.CreatedAt),
+		Details: map[string]string{
+			"version": aws.ToString(cluster.Version),
+		},
+	}
+
+	// API server endpoint is an URL, keep host only so it is searchable as DNS name
+	if endpoint, err := url.Parse(aws.ToString(cluster.Endpoint)); err == nil {
+		item.PrivateDNSName = endpoint.Host
+	}
+
+	if cluster.ResourcesVpcConfig != nil {
+		item.VpcID = aws.ToString(cluster.ResourcesVpcConfig.VpcId)
+	}
+
+	return item
+}
+
+func (r *EKS) nodegroupItem(nodegroup types.Nodegroup) Item {
+	details := map[string]string{
+		"cluster":        aws.ToString(nodegroup.ClusterName),
+		"version":        aws.ToString(nodegroup.Version),
+		"capacity_type":  string(nodegroup.CapacityType),
+		"instance_types": strings.Join(nodegroup.InstanceTypes, ","),
+	}
+
+	if nodegroup.Resources != nil {
+		groups := []string{}
+		for _, group := range nodegroup.Resources.AutoScalingGroups {
+			groups = append(groups, aws.ToString(group.Name))
+		}
+		details["autoscaling_groups"] = strings.Join(groups, ",")
+	}
+
+	for k, v := range details {
+		if v == "" {
+			delete(details, k)
+		}
+	}
+
+	return Item{
+		ID:           aws.ToString(nodegroup.NodegroupName),
+		ARN:          aws.ToString(nodegroup.NodegroupArn),
+		Name:         aws.ToString(nodegroup.NodegroupName),
+		Type:         "EKS node group",
+		Tags:         mapTags(nodegroup.Tags),
+		Account:      r.AccountID,
+		AccountAlias: r.AccountAlias,
+		Region:       r.Region,
+		State:        string(nodegroup.Status),
+		CreatedAt:    aws.ToTime(nodegroup.CreatedAt),
+		Details:      details,
+	}
+}
+
+// mapTags converts tags returned as a map to item tags.
+func mapTags(tagMap map[string]string) []ItemTag {
+	tags := []ItemTag{}
+	for k, v := range tagMap {
+		tags = append(tags, ItemTag{
+			Key:   k,
+			Value: v,
+		})
+	}
+
+	return tags
+}
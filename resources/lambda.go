@@ -9,6 +9,20 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/lambda/types"
 )
 
+func init() {
+	Register(Collector{
+		Name:        "lambda",
+		DisplayType: "Lambda function",
+		TaggingType: "lambda:function",
+		New: func(cfg aws.Config, base BaseAWSResource) AWSResourceType {
+			return &LambdaFunction{
+				Client:          lambda.NewFromConfig(cfg),
+				BaseAWSResource: base,
+			}
+		},
+	})
+}
+
 type LambdaFunction struct {
 	Client *lambda.Client
 	BaseAWSResource
@@ -18,63 +32,76 @@ func (r *LambdaFunction) GetCacheKey() string {
 	return fmt.Sprintf("%s-%s-%s", r.AccountID, r.Region, r.Type)
 }
 
-func (r *LambdaFunction) Get() ([]Item, error) {
+func (r *LambdaFunction) Get(ctx context.Context) ([]Item, error) {
 	items := []Item{}
 
-	functions, err := r.listFunctions(100)
+	functions, err := r.listFunctions(ctx)
 	if err != nil {
 		return nil, err
 	}
 
 	for _, function := range functions {
 
-		lambdaTagsInput := lambda.ListTagsInput{
-			Resource: function.FunctionArn,
-		}
+		tags := []ItemTag{}
 
-		tagsList, err := r.Client.ListTags(context.TODO(), &lambdaTagsInput)
-		if err != nil {
-			return nil, err
-		}
+		if !r.SkipTags {
+			lambdaTagsInput := lambda.ListTagsInput{
+				Resource: function.FunctionArn,
+			}
 
-		tags := []ItemTag{}
-		for k, v := range tagsList.Tags {
-			newTag := ItemTag{
-				Key:   k,
-				Value: v,
+			tagsList, err := r.Client.ListTags(ctx, &lambdaTagsInput)
+			if err != nil {
+				return nil, err
 			}
 
-			tags = append(tags, newTag)
+			for k, v := range tagsList.Tags {
+				newTag := ItemTag{
+					Key:   k,
+					Value: v,
+				}
+
+				tags = append(tags, newTag)
+			}
 		}
 
 		item := Item{
 			Tags:         tags,
 			ID:           *function.FunctionName,
 			ARN:          *function.FunctionArn,
+			Name:         *function.FunctionName,
 			Type:         "Lambda function",
 			Account:      r.AccountID,
 			AccountAlias: r.AccountAlias,
 			Region:       r.Region,
 		}
 
+		if function.VpcConfig != nil {
+			item.VpcID = aws.ToString(function.VpcConfig.VpcId)
+			item.SetSubnets(function.VpcConfig.SubnetIds)
+		}
+
 		items = append(items, item)
 	}
 
 	return items, nil
 }
 
-func (r *LambdaFunction) listFunctions(maxItems int) ([]types.FunctionConfiguration, error) {
+func (r *LambdaFunction) listFunctions(ctx context.Context) ([]types.FunctionConfiguration, error) {
 	var functions []types.FunctionConfiguration
 	paginator := lambda.NewListFunctionsPaginator(r.Client, &lambda.ListFunctionsInput{
-		MaxItems: aws.Int32(int32(maxItems)),
+		MaxItems: r.PageSize(),
 	})
-	for paginator.HasMorePages() && len(functions) < maxItems {
-		pageOutput, err := paginator.NextPage(context.TODO())
+	for paginator.HasMorePages() && !r.LimitReached(len(functions)) {
+		pageOutput, err := paginator.NextPage(ctx)
 		if err != nil {
 			return nil, err
 		}
 		functions = append(functions, pageOutput.Functions...)
 	}
 
+	if r.LimitReached(len(functions)) {
+		functions = functions[:r.MaxItems]
+	}
+
 	return functions, nil
 }
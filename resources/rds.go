@@ -0,0 +1,147 @@
+package resources
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/rds"
+	"github.com/aws/aws-sdk-go-v2/service/rds/types"
+)
+
+func init() {
+	Register(Collector{
+		Name:        "rds",
+		DisplayType: "RDS",
+		New: func(cfg aws.Config, base BaseAWSResource) AWSResourceType {
+			return &RDS{
+				Client:          rds.NewFromConfig(cfg),
+				BaseAWSResource: base,
+			}
+		},
+	})
+}
+
+// RDS collects DB instances and Aurora DB clusters. Tags come with the
+// describe calls, so no tagging API type is set.
+type RDS struct {
+	Client *rds.Client
+	BaseAWSResource
+}
+
+func (r *RDS) GetCacheKey() string {
+	return fmt.Sprintf("%s-%s-%s", r.AccountID, r.Region, r.Type)
+}
+
+func (r *RDS) Get(ctx context.Context) ([]Item, error) {
+	items := []Item{}
+
+	clusters := rds.NewDescribeDBClustersPaginator(r.Client, &rds.DescribeDBClustersInput{
+		MaxRecords: r.PageSize(),
+	})
+	for clusters.HasMorePages() && !r.LimitReached(len(items)) {
+		pageOutput, err := clusters.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, cluster := range pageOutput.DBClusters {
+			items = append(items, r.clusterItem(cluster))
+		}
+	}
+
+	instances := rds.NewDescribeDBInstancesPaginator(r.Client, &rds.DescribeDBInstancesInput{
+		MaxRecords: r.PageSize(),
+	})
+	for instances.HasMorePages() && !r.LimitReached(len(items)) {
+		pageOutput, err := instances.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, instance := range pageOutput.DBInstances {
+			items = append(items, r.instanceItem(instance))
+		}
+	}
+
+	return r.Limit(items), nil
+}
+
+func (r *RDS) clusterItem(cluster types.DBCluster) Item {
+	details := map[string]string{
+		"engine":         aws.ToString(cluster.Engine),
+		"engine_version": aws.ToString(cluster.EngineVersion),
+	}
+	if cluster.Port != nil {
+		details["port"] = strconv.Itoa(int(*cluster.Port))
+	}
+	if cluster.ReaderEndpoint != nil {
+		details["reader_endpoint"] = *cluster.ReaderEndpoint
+	}
+
+	return Item{
+		ID:             aws.ToString(cluster.DBClusterIdentifier),
+		ARN:            aws.ToString(cluster.DBClusterArn),
+		Name:           aws.ToString(cluster.DBClusterIdentifier),
+		Type:           "RDS cluster",
+		Tags:           rdsTags(cluster.TagList),
+		Account:        r.AccountID,
+		AccountAlias:   r.AccountAlias,
+		Region:         r.Region,
+		PrivateDNSName: aws.ToString(cluster.Endpoint),
+		State:          aws.ToString(cluster.Status),
+		CreatedAt:      aws.ToTime(cluster.ClusterCreateTime),
+		Details:        details,
+	}
+}
+
+func (r *RDS) instanceItem(instance types.DBInstance) Item {
+	details := map[string]string{
+		"engine":         aws.ToString(instance.Engine),
+		"engine_version": aws.ToString(instance.EngineVersion),
+		"class":          aws.ToString(instance.DBInstanceClass),
+	}
+	if instance.DBClusterIdentifier != nil {
+		details["cluster"] = *instance.DBClusterIdentifier
+	}
+
+	item := Item{
+		ID:           aws.ToString(instance.DBInstanceIdentifier),
+		ARN:          aws.ToString(instance.DBInstanceArn),
+		Name:         aws.ToString(instance.DBInstanceIdentifier),
+		Type:         "RDS instance",
+		Tags:         rdsTags(instance.TagList),
+		Account:      r.AccountID,
+		AccountAlias: r.AccountAlias,
+		Region:       r.Region,
+		State:        aws.ToString(instance.DBInstanceStatus),
+		CreatedAt:    aws.ToTime(instance.InstanceCreateTime),
+		Details:      details,
+	}
+
+	if instance.Endpoint != nil {
+		item.PrivateDNSName = aws.ToString(instance.Endpoint.Address)
+		if instance.Endpoint.Port != nil {
+			details["port"] = strconv.Itoa(int(*instance.Endpoint.Port))
+		}
+	}
+
+	if instance.DBSubnetGroup != nil {
+		item.VpcID = aws.ToString(instance.DBSubnetGroup.VpcId)
+	}
+
+	return item
+}
+
+func rdsTags(tagList []types.Tag) []ItemTag {
+	tags := []ItemTag{}
+	for _, tag := range tagList {
+		tags = append(tags, ItemTag{
+			Key:   aws.ToString(tag.Key),
+			Value: aws.ToString(tag.Value),
+		})
+	}
+
+	return tags
+}
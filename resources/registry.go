@@ -0,0 +1,75 @@
+package resources
+
+import (
+	"maps"
+	"slices"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+)
+
+// GlobalRegion is the region of global collectors in cache keys and sources.
+const GlobalRegion = "global"
+
+// Constructor builds a collector for a single account and region.
+type Constructor func(cfg aws.Config, base BaseAWSResource) AWSResourceType
+
+// Collector describes a registered resource type.
+type Collector struct {
+	// Name is the value used in the "resources" list of the config.
+	Name string
+	// DisplayType is the human readable type shown in the UI.
+	DisplayType string
+	// Regions limits the collector to the given regions, empty means all regions.
+	Regions []string
+	// Global collectors run once per account instead of once per region.
+	Global bool
+	// TaggingType is the Resource Groups Tagging API resource type. When set,
+	// the collector skips its own tag calls in tagging API mode.
+	TaggingType string
+	New         Constructor
+}
+
+// SupportsRegion reports whether the collector should run in region.
+func (c Collector) SupportsRegion(region string) bool {
+	return len(c.Regions) == 0 || slices.Contains(c.Regions, region)
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   = map[string]Collector{}
+)
+
+// Register makes a collector available under c.Name. It panics when the name
+// is empty or already registered, as that is always a programming error.
+func Register(c Collector) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	if c.Name == "" || c.New == nil {
+		panic("resources: Register called with empty name or nil constructor")
+	}
+
+	if _, dup := registry[c.Name]; dup {
+		panic("resources: Register called twice for " + c.Name)
+	}
+
+	registry[c.Name] = c
+}
+
+// GetCollector returns the collector registered under name.
+func GetCollector(name string) (Collector, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	c, ok := registry[name]
+	return c, ok
+}
+
+// CollectorNames returns names of all registered collectors, sorted.
+func CollectorNames() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	return slices.Sorted(maps.Keys(registry))
+}
@@ -0,0 +1,182 @@
+package resources
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/route53"
+	"github.com/aws/aws-sdk-go-v2/service/route53/types"
+)
+
+func init() {
+	Register(Collector{
+		Name:        "route53",
+		DisplayType: "DNS",
+		Global:      true,
+		New: func(cfg aws.Config, base BaseAWSResource) AWSResourceType {
+			return &Route53{
+				Client:          route53.NewFromConfig(cfg),
+				BaseAWSResource: base,
+			}
+		},
+	})
+}
+
+// Route53 collects hosted zones and their A, AAAA and CNAME records,
+// including aliases. Record targets are resolved to inventory items on search.
+type Route53 struct {
+	Client *route53.Client
+	BaseAWSResource
+}
+
+func (r *Route53) GetCacheKey() string {
+	return fmt.Sprintf("%s-%s-%s", r.AccountID, r.Region, r.Type)
+}
+
+// partition returns ARN partition of the account, Region of the global
+// collector is not a real one, the client region is.
+func (r *Route53) partition() string {
+	return Partition(r.Client.Options().Region)
+}
+
+func (r *Route53) Get(ctx context.Context) ([]Item, error) {
+	items := []Item{}
+
+	paginator := route53.NewListHostedZonesPaginator(r.Client, &route53.ListHostedZonesInput{
+		MaxItems: r.PageSize(),
+	})
+
+	for paginator.HasMorePages() && !r.LimitReached(len(items)) {
+		result, err := paginator.NextPage(ctx)
+		if err != nil {
+			return items, err
+		}
+
+		for _, zone := range result.HostedZones {
+			zoneItem, err := r.zoneItem(ctx, zone)
+			if err != nil {
+				return items, err
+			}
+			items = append(items, zoneItem)
+
+			records, err := r.records(ctx, zone)
+			if err != nil {
+				return items, err
+			}
+			items = append(items, records...)
+		}
+	}
+
+	return r.Limit(items), nil
+}
+
+func (r *Route53) zoneItem(ctx context.Context, zone types.HostedZone) (Item, error) {
+	zoneID := strings.TrimPrefix(aws.ToString(zone.Id), "/hostedzone/")
+
+	tags := []ItemTag{}
+	if !r.SkipTags {
+		result, err := r.Client.ListTagsForResource(ctx, &route53.ListTagsForResourceInput{
+			ResourceId:   aws.String(zoneID),
+			ResourceType: types.TagResourceTypeHostedzone,
+		})
+		if err != nil {
+			return Item{}, err
+		}
+
+		if result.ResourceTagSet != nil {
+			for _, tag := range result.ResourceTagSet.Tags {
+				tags = append(tags, ItemTag{
+					Key:   aws.ToString(tag.Key),
+					Value: aws.ToString(tag.Value),
+				})
+			}
+		}
+	}
+
+	return Item{
+		ID:             zoneID,
+		ARN:            fmt.Sprintf("arn:%s:route53:::hostedzone/%s", r.partition(), zoneID),
+		Name:           dnsName(aws.ToString(zone.Name)),
+		Type:           "DNS zone",
+		Tags:           tags,
+		Account:        r.AccountID,
+		AccountAlias:   r.AccountAlias,
+		Region:         r.Region,
+		PrivateDNSName: dnsName(aws.ToString(zone.Name)),
+		Details: map[string]string{
+			"private": strconv.FormatBool(zone.Config != nil && zone.Config.PrivateZone),
+			"records": strconv.FormatInt(aws.ToInt64(zone.ResourceRecordSetCount), 10),
+		},
+	}, nil
+}
+
+func (r *Route53) records(ctx context.Context, zone types.HostedZone) ([]Item, error) {
+	items := []Item{}
+	zoneID := strings.TrimPrefix(aws.ToString(zone.Id), "/hostedzone/")
+
+	paginator := route53.NewListResourceRecordSetsPaginator(r.Client, &route53.ListResourceRecordSetsInput{
+		HostedZoneId: zone.Id,
+		MaxItems:     r.PageSize(),
+	})
+
+	for paginator.HasMorePages() {
+		result, err := paginator.NextPage(ctx)
+		if err != nil {
+			return items, err
+		}
+
+		for _, record := range result.ResourceRecordSets {
+			switch record.Type {
+			case types.RRTypeA, types.RRTypeAaaa, types.RRTypeCname:
+			default:
+				continue
+			}
+
+			name := dnsName(aws.ToString(record.Name))
+
+			details := map[string]string{
+				"zone":    dnsName(aws.ToString(zone.Name)),
+				"zone_id": zoneID,
+				"private": strconv.FormatBool(zone.Config != nil && zone.Config.PrivateZone),
+			}
+			if record.SetIdentifier != nil {
+				details["set_identifier"] = *record.SetIdentifier
+			}
+
+			item := Item{
+				ID:             name,
+				Name:           name,
+				Type:           fmt.Sprintf("DNS record (%s)", record.Type),
+				Tags:           []ItemTag{},
+				Account:        r.AccountID,
+				AccountAlias:   r.AccountAlias,
+				Region:         r.Region,
+				PrivateDNSName: name,
+				Details:        details,
+			}
+
+			if record.AliasTarget != nil {
+				details["alias"] = "true"
+				item.Targets = append(item.Targets, dnsName(aws.ToString(record.AliasTarget.DNSName)))
+			}
+
+			for _, value := range record.ResourceRecords {
+				item.Targets = append(item.Targets, dnsName(aws.ToString(value.Value)))
+			}
+
+			items = append(items, item)
+		}
+	}
+
+	return items, nil
+}
+
+// dnsName normalizes name returned by Route53: lower case, without the
+// trailing dot and with the escaped wildcard label.
+func dnsName(name string) string {
+	name = strings.ReplaceAll(name, `\052`, "*")
+	return strings.ToLower(strings.TrimSuffix(name, "."))
+}
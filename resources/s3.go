@@ -0,0 +1,218 @@
+package resources
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aws/smithy-go"
+)
+
+func init() {
+	Register(Collector{
+		Name:        "s3",
+		DisplayType: "S3 bucket",
+		New: func(cfg aws.Config, base BaseAWSResource) AWSResourceType {
+			return &S3Bucket{
+				Client:          s3.NewFromConfig(cfg),
+				BaseAWSResource: base,
+			}
+		},
+	})
+}
+
+// S3Bucket collects buckets living in its region. Buckets of an account are
+// listed once, by the BucketListing shared by collectors of all its regions.
+type S3Bucket struct {
+	Client *s3.Client
+	BaseAWSResource
+}
+
+func (r *S3Bucket) GetCacheKey() string {
+	return fmt.Sprintf("%s-%s-%s", r.AccountID, r.Region, r.Type)
+}
+
+func (r *S3Bucket) Get(ctx context.Context) ([]Item, error) {
+	items := []Item{}
+
+	if r.Buckets == nil {
+		return items, errors.New("s3 collector requires a bucket listing")
+	}
+
+	buckets, err := r.Buckets.regionBuckets(ctx, r.Client, r.PageSize(), r.Region)
+	if err != nil {
+		return items, err
+	}
+
+	for _, bucket := range buckets {
+		if r.LimitReached(len(items)) {
+			break
+		}
+
+		// a bucket the reader cannot inspect does not fail the others,
+		// its error is kept in item details
+		var details map[string]string
+		if bucket.err != nil {
+			details = map[string]string{"error": bucket.err.Error()}
+		}
+
+		tags := []ItemTag{}
+		if !r.SkipTags && bucket.err == nil {
+			if tags, err = r.bucketTags(ctx, &bucket.name); err != nil {
+				if ctx.Err() != nil {
+					return items, ctx.Err()
+				}
+				slog.Warn("Bucket tagging failed", "bucket", bucket.name, "error", err)
+				details = map[string]string{"error": err.Error()}
+				tags = []ItemTag{}
+			}
+		}
+
+		items = append(items, Item{
+			ID:           bucket.name,
+			ARN:          fmt.Sprintf("arn:%s:s3:::%s", Partition(r.Region), bucket.name),
+			Name:         bucket.name,
+			Type:         "S3 bucket",
+			Tags:         tags,
+			Account:      r.AccountID,
+			AccountAlias: r.AccountAlias,
+			Region:       r.Region,
+			CreatedAt:    bucket.createdAt,
+			Details:      details,
+		})
+	}
+
+	return items, nil
+}
+
+// bucketTags returns tags of bucket, the client is in the bucket region.
+func (r *S3Bucket) bucketTags(ctx context.Context, bucket *string) ([]ItemTag, error) {
+	tags := []ItemTag{}
+
+	result, err := r.Client.GetBucketTagging(ctx, &s3.GetBucketTaggingInput{
+		Bucket: bucket,
+	})
+	if err != nil {
+		var apiErr smithy.APIError
+		if errors.As(err, &apiErr) && apiErr.ErrorCode() == "NoSuchTagSet" {
+			return tags, nil
+		}
+		return nil, err
+	}
+
+	for _, tag := range result.TagSet {
+		tags = append(tags, ItemTag{
+			Key:   *tag.Key,
+			Value: *tag.Value,
+		})
+	}
+
+	return tags, nil
+}
+
+// listedBucket is a bucket found by BucketListing, err is set when its
+// location could not be read.
+type listedBucket struct {
+	name      string
+	createdAt time.Time
+	err       error
+}
+
+// BucketListing lists buckets of an account with their regions once, when
+// the first S3 collector of the account runs, and hands them out by region.
+type BucketListing struct {
+	// FallbackRegion gets buckets whose location could not be read.
+	FallbackRegion string
+	// Timeout limits the listing, zero means no limit.
+	Timeout time.Duration
+
+	once    sync.Once
+	buckets map[string][]listedBucket
+	err     error
+}
+
+// regionBuckets returns buckets living in region, listing them with client on first use.
+func (l *BucketListing) regionBuckets(ctx context.Context, client *s3.Client, pageSize *int32, region string) ([]listedBucket, error) {
+	// the listing is shared, so it must not end with the collector that started it
+	l.once.Do(func() {
+		ctx := context.WithoutCancel(ctx)
+		if l.Timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, l.Timeout)
+			defer cancel()
+		}
+
+		l.list(ctx, client, pageSize)
+	})
+
+	if l.err != nil {
+		return nil, l.err
+	}
+
+	return l.buckets[region], nil
+}
+
+func (l *BucketListing) list(ctx context.Context, client *s3.Client, pageSize *int32) {
+	l.buckets = map[string][]listedBucket{}
+
+	paginator := s3.NewListBucketsPaginator(client, &s3.ListBucketsInput{
+		MaxBuckets: pageSize,
+	})
+
+	for paginator.HasMorePages() {
+		result, err := paginator.NextPage(ctx)
+		if err != nil {
+			l.err = err
+			return
+		}
+
+		for _, bucket := range result.Buckets {
+			listed := listedBucket{
+				name:      aws.ToString(bucket.Name),
+				createdAt: aws.ToTime(bucket.CreationDate),
+			}
+
+			region := aws.ToString(bucket.BucketRegion)
+			if region == "" {
+				region, listed.err = bucketRegion(ctx, client, bucket.Name)
+			}
+
+			if listed.err != nil {
+				if ctx.Err() != nil {
+					l.err = ctx.Err()
+					return
+				}
+				slog.Warn("Bucket location failed", "bucket", listed.name, "error", listed.err)
+				region = l.FallbackRegion
+			}
+
+			l.buckets[region] = append(l.buckets[region], listed)
+		}
+	}
+}
+
+// bucketRegion returns region of bucket, GetBucketLocation reports us-east-1
+// as empty constraint and eu-west-1 as legacy "EU".
+func bucketRegion(ctx context.Context, client *s3.Client, bucket *string) (string, error) {
+	location, err := client.GetBucketLocation(ctx, &s3.GetBucketLocationInput{
+		Bucket: bucket,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	switch location.LocationConstraint {
+	case "":
+		return "us-east-1", nil
+	case types.BucketLocationConstraintEu:
+		return "eu-west-1", nil
+	}
+
+	return string(location.LocationConstraint), nil
+}
@@ -0,0 +1,77 @@
+package resources
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/resourcegroupstaggingapi"
+)
+
+// TagSweep fetches tags of all resources of given types in a region with the
+// Resource Groups Tagging API. The sweep runs once, on first Apply.
+type TagSweep struct {
+	Client        *resourcegroupstaggingapi.Client
+	ResourceTypes []string
+	// Timeout limits the sweep, zero means no limit.
+	Timeout time.Duration
+
+	once sync.Once
+	tags map[string][]ItemTag
+	err  error
+}
+
+func (t *TagSweep) sweep(ctx context.Context) {
+	t.tags = map[string][]ItemTag{}
+
+	paginator := resourcegroupstaggingapi.NewGetResourcesPaginator(t.Client, &resourcegroupstaggingapi.GetResourcesInput{
+		ResourceTypeFilters: t.ResourceTypes,
+	})
+
+	for paginator.HasMorePages() {
+		result, err := paginator.NextPage(ctx)
+		if err != nil {
+			t.err = err
+			return
+		}
+
+		for _, mapping := range result.ResourceTagMappingList {
+			tags := []ItemTag{}
+			for _, tag := range mapping.Tags {
+				tags = append(tags, ItemTag{
+					Key:   *tag.Key,
+					Value: *tag.Value,
+				})
+			}
+
+			t.tags[*mapping.ResourceARN] = tags
+		}
+	}
+}
+
+// Apply sets tags of items found in the sweep, matching them by ARN.
+func (t *TagSweep) Apply(ctx context.Context, items []Item) error {
+	// the sweep is shared, so it must not end with the collector that started it
+	t.once.Do(func() {
+		ctx := context.WithoutCancel(ctx)
+		if t.Timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, t.Timeout)
+			defer cancel()
+		}
+
+		t.sweep(ctx)
+	})
+
+	if t.err != nil {
+		return t.err
+	}
+
+	for i := range items {
+		if tags, ok := t.tags[items[i].ARN]; ok {
+			items[i].Tags = tags
+		}
+	}
+
+	return nil
+}
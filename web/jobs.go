@@ -0,0 +1,98 @@
+package web
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/wasilak/cloudpile/cache"
+	"github.com/wasilak/cloudpile/libs"
+	"github.com/wasilak/cloudpile/resources"
+)
+
+// RefreshJob is a cache refresh running in background.
+type RefreshJob struct {
+	ID         string             `json:"id"`
+	Status     string             `json:"status"`
+	Filter     libs.Filter        `json:"filter"`
+	StartedAt  time.Time          `json:"startedAt"`
+	FinishedAt *time.Time         `json:"finishedAt,omitempty"`
+	Sources    []resources.Source `json:"sources,omitempty"`
+	Error      string             `json:"error,omitempty"`
+}
+
+const (
+	jobRunning = "running"
+	jobDone    = "done"
+	jobFailed  = "failed"
+
+	// finished jobs are forgotten after jobRetention
+	jobRetention = time.Hour
+)
+
+var (
+	jobsMu sync.Mutex
+	jobs   = map[string]*RefreshJob{}
+)
+
+// startRefreshJob runs refresh in background. Job lives as long as ctx, not
+// as long as the request which started it.
+func startRefreshJob(ctx context.Context, filter libs.Filter) (RefreshJob, error) {
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return RefreshJob{}, err
+	}
+
+	job := &RefreshJob{
+		ID:        hex.EncodeToString(id),
+		Status:    jobRunning,
+		Filter:    filter,
+		StartedAt: time.Now(),
+	}
+
+	jobsMu.Lock()
+	jobs[job.ID] = job
+	snapshot := *job
+	jobsMu.Unlock()
+
+	go func() {
+		result, err := libs.Refresh(ctx, cache.CacheInstance, filter)
+
+		jobsMu.Lock()
+		defer jobsMu.Unlock()
+
+		finishedAt := time.Now()
+		job.FinishedAt = &finishedAt
+		job.Sources = result.Sources
+		job.Status = jobDone
+
+		if err != nil {
+			slog.Error("Cache refresh failed", "job", job.ID, "error", err)
+			job.Status = jobFailed
+			job.Error = err.Error()
+		}
+
+		time.AfterFunc(jobRetention, func() {
+			jobsMu.Lock()
+			defer jobsMu.Unlock()
+			delete(jobs, job.ID)
+		})
+	}()
+
+	return snapshot, nil
+}
+
+func getRefreshJob(id string) (RefreshJob, bool) {
+	jobsMu.Lock()
+	defer jobsMu.Unlock()
+
+	job, ok := jobs[id]
+	if !ok {
+		return RefreshJob{}, false
+	}
+
+	return *job, true
+}
@@ -1,7 +1,10 @@
 package web
 
 import (
+	"errors"
 	"net/http"
+	"net/url"
+	"strconv"
 	"strings"
 
 	"log/slog"
@@ -23,16 +26,12 @@ func ApiConfigRoute(c echo.Context) error {
 }
 
 func SearchRoute(c echo.Context) error {
-	var ids []string
+	query := strings.TrimSpace(c.QueryParam("id"))
 
-	ids = strings.Split(strings.Replace(c.QueryParam("id"), "%2C", ",", -1), ",")
-	ids = libs.RemoveEmptyStrings(ids)
-	ids = libs.Deduplicate(ids)
-
-	slog.Debug("QueryDebug", "QueryParam('id')", c.QueryParam("id"), "ids", slog.AnyValue(ids))
+	slog.Debug("QueryDebug", "QueryParam('id')", c.QueryParam("id"), "query", query)
 
 	tempalateData := map[string]string{
-		"IDs": strings.Join(ids, ","),
+		"Query": query,
 	}
 
 	return c.Render(http.StatusOK, "search", tempalateData)
@@ -44,33 +43,127 @@ func ListRoute(c echo.Context) error {
 }
 
 func ApiSearchRoute(c echo.Context) error {
-	var ids []string
+	// ARNs contain slashes, so query parameter is preferred over path
+	queryString := c.QueryParam("q")
+	if queryString == "" {
+		var err error
+		queryString, err = url.PathUnescape(c.Param("id"))
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+	}
 
-	ids = strings.Split(strings.Replace(c.Param("id"), "%2C", ",", -1), ",")
-	ids = libs.RemoveEmptyStrings(ids)
-	ids = libs.Deduplicate(ids)
+	slog.Debug("QueryDebug", "QueryParam('q')", c.QueryParam("q"), "Param('id')", c.Param("id"), "query", queryString)
 
-	slog.Debug("QueryDebug", "QueryParam('id')", c.QueryParam("id"), "ids", slog.AnyValue(ids))
+	query, err := libs.ParseQuery(queryString)
+	if err != nil {
+		var queryErr *libs.QueryError
+		if errors.As(err, &queryErr) {
+			return c.JSON(http.StatusBadRequest, map[string]interface{}{
+				"message":  queryErr.Error(),
+				"position": queryErr.Position,
+				"token":    queryErr.Token,
+			})
+		}
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
 
-	var items []resources.Item
-	if len(ids) > 0 {
-		var err error
-		items, err = libs.Run(ids, cache.CacheInstance, false)
+	result := libs.Result{
+		Items:   []resources.Item{},
+		Sources: []resources.Source{},
+	}
+	if query != nil {
+		result, err = libs.Run(c.Request().Context(), query, cache.CacheInstance, libs.FromCache)
 		if err != nil {
 			return echo.NewHTTPError(http.StatusInternalServerError, err)
 		}
 	}
 
-	return c.JSON(http.StatusOK, items)
+	return c.JSON(http.StatusOK, result)
 }
 
 func ApiListRoute(c echo.Context) error {
-	var ids []string
+	result, err := libs.Run(c.Request().Context(), nil, cache.CacheInstance, libs.FromCache)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err)
+	}
 
-	items, err := libs.Run(ids, cache.CacheInstance, false)
+	return c.JSON(http.StatusOK, result)
+}
+
+// ApiIPRoute returns items with an address in the given address, CIDR or
+// address range, grouped by account and VPC.
+func ApiIPRoute(c echo.Context) error {
+	value, err := url.PathUnescape(c.Param("*"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	query, err := libs.ParseIPRange(value)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	result, err := libs.Run(c.Request().Context(), query, cache.CacheInstance, libs.FromCache)
 	if err != nil {
 		return echo.NewHTTPError(http.StatusInternalServerError, err)
 	}
 
-	return c.JSON(http.StatusOK, items)
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"groups":  libs.GroupByNetwork(result.Items),
+		"sources": result.Sources,
+	})
+}
+
+func ApiCacheRoute(c echo.Context) error {
+	if !cache.CacheInstance.Enabled {
+		return echo.NewHTTPError(http.StatusNotFound, "cache is disabled")
+	}
+
+	statuses, err := cache.CacheInstance.Statuses()
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err)
+	}
+
+	return c.JSON(http.StatusOK, statuses)
+}
+
+func ApiCacheRefreshRoute(c echo.Context) error {
+	if !cache.CacheInstance.Enabled {
+		return echo.NewHTTPError(http.StatusNotFound, "cache is disabled")
+	}
+
+	filter := libs.Filter{
+		AccountAlias: c.QueryParam("account"),
+		Region:       c.QueryParam("region"),
+		Type:         c.QueryParam("type"),
+	}
+
+	async, _ := strconv.ParseBool(c.QueryParam("async"))
+	if async {
+		job, err := startRefreshJob(serverCtx, filter)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, err)
+		}
+
+		return c.JSON(http.StatusAccepted, job)
+	}
+
+	result, err := libs.Refresh(c.Request().Context(), cache.CacheInstance, filter)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"sources": result.Sources,
+	})
+}
+
+func ApiCacheRefreshJobRoute(c echo.Context) error {
+	job, ok := getRefreshJob(c.Param("id"))
+	if !ok {
+		return echo.NewHTTPError(http.StatusNotFound, "refresh job not found")
+	}
+
+	return c.JSON(http.StatusOK, job)
 }
@@ -1,11 +1,14 @@
 package web
 
 import (
+	"context"
 	"embed"
 	"io"
 	"io/fs"
+	"net"
 	"net/http"
 	"text/template"
+	"time"
 
 	"log/slog"
 
@@ -19,6 +22,9 @@ import (
 //go:embed views
 var views embed.FS
 
+// serverCtx lives until server shutdown, background work started by requests uses it.
+var serverCtx = context.Background()
+
 //go:embed assets
 var assets embed.FS
 
@@ -48,7 +54,11 @@ func getEmbededAssets() http.FileSystem {
 	return http.FS(fsys)
 }
 
-func Web() {
+// Web serves the UI and API until ctx is done, then shuts the server down
+// gracefully. Requests in flight share ctx, so their collection is cancelled too.
+func Web(ctx context.Context) error {
+	serverCtx = ctx
+
 	e := echo.New()
 
 	e.Use(middleware.Gzip())
@@ -81,6 +91,30 @@ func Web() {
 	e.GET("/api/search/", ApiSearchRoute)
 	e.GET("/api/search/:id", ApiSearchRoute)
 	e.GET("/api/config/", ApiConfigRoute)
+	e.GET("/api/ip/*", ApiIPRoute)
+	e.GET("/api/cache", ApiCacheRoute)
+	e.POST("/api/cache/refresh", ApiCacheRefreshRoute)
+	e.GET("/api/cache/refresh/:id", ApiCacheRefreshJobRoute)
+
+	e.Server.BaseContext = func(net.Listener) context.Context {
+		return ctx
+	}
+
+	errChan := make(chan error, 1)
+	go func() {
+		errChan <- e.Start(viper.GetString("listen"))
+	}()
+
+	select {
+	case err := <-errChan:
+		return err
+	case <-ctx.Done():
+	}
+
+	slog.Info("Shutting down")
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
 
-	e.Logger.Fatal(e.Start(viper.GetString("listen")))
+	return e.Shutdown(shutdownCtx)
 }